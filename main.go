@@ -1,14 +1,20 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
 	"github.com/codingconcepts/db-chaos/pkg/repo"
+	"github.com/codingconcepts/db-chaos/pkg/report"
 	"github.com/codingconcepts/db-chaos/pkg/runner"
 	"github.com/samber/lo"
 )
@@ -16,49 +22,191 @@ import (
 func main() {
 	log.SetFlags(0)
 
+	if len(os.Args) > 1 && os.Args[1] == "cleanup" {
+		cleanup(os.Args[2:])
+		return
+	}
+
 	var r runner.WorkloadRunner
+	var chaosOpts runner.ChaosOptions
 
 	database := flag.String("database", "postgres", "the database under test [oracle | postgres]")
 	url := flag.String("url", "", "database connection string")
-	ns := flag.String("namespace", "default", "database namespace")
-	chaosNS := flag.String("chaos-namespace", "chaos-mesh", "chaos mesh namespace")
+	flag.StringVar(&chaosOpts.Namespace, "namespace", "default", "database namespace")
+	flag.StringVar(&chaosOpts.ChaosNamespace, "chaos-namespace", "chaos-mesh", "chaos mesh namespace")
+	flag.StringVar(&chaosOpts.Selector, "selector", "", "label selector for database pods (e.g. app=cockroachdb)")
+	flag.StringVar(&chaosOpts.StatefulSet, "statefulset", "", "only target pods owned by this statefulset")
 	expDuration := flag.Duration("experiment-duration", time.Second*30, "length of each chaos experiment")
-	readyTimeout := flag.Duration("ready-timeout", time.Second*60, "amount of time to wait for ready pods")
+	flag.DurationVar(&chaosOpts.ReadyTimeout, "ready-timeout", time.Second*60, "amount of time to wait for ready pods")
+	flag.DurationVar(&chaosOpts.InjectTimeout, "inject-timeout", time.Second*30, "amount of time to wait for faults to be injected or recovered")
+	flag.IntVar(&chaosOpts.ReplicationFactor, "replication-factor", 0, "database replication factor, used to limit the number of pods faulted at once by concurrent experiments")
+	flag.IntVar(&chaosOpts.MaxFaulted, "max-faulted", 0, "maximum number of pods faulted at once by concurrent experiments (defaults to a minority of --replication-factor)")
+	reportPath := flag.String("report", "", "path to write a JSON report of the run to")
+	flag.StringVar(&chaosOpts.Backend, "backend", runner.BackendChaosMesh, "fault injection backend [chaos-mesh | kubernetes | toxiproxy | proxy | local]")
+	flag.StringVar(&chaosOpts.HelperImage, "helper-image", "busybox:1.36", "image of the privileged helper pods that freeze processes and stop kubelets")
+	flag.BoolVar(&chaosOpts.Workflow, "workflow", false, "submit the scenario as a single chaos mesh workflow and follow its progress")
+	flag.StringVar(&chaosOpts.ResumeWorkflow, "resume-workflow", "", "follow a workflow submitted by an earlier run, by name or run id, instead of submitting the scenario")
+	schedule := flag.String("schedule", "", "submit the scenario as a chaos mesh schedule with this cron expression (e.g. \"@every 6h\") and exit")
+	dryRun := flag.Bool("dry-run", false, "print the chaos mesh objects the scenario would create as YAML and exit")
+	targets := flag.String("targets", "", "comma-separated database pods to use instead of discovering them (e.g. for --dry-run without a cluster)")
+	flag.Uint64Var(&chaosOpts.Seed, "seed", 0, "seed for random choices such as nemesis schedules, to replay a run (defaults to a random seed)")
+	scenarioPath := flag.String("scenario", "", "path to a YAML or JSON scenario file (defaults to the built-in scenario)")
 	flag.BoolVar(&r.Reseed, "reseed", false, "reseed the database with test data")
 	flag.IntVar(&r.Accounts, "accounts", 10000, "number of accounts in bank")
 	flag.IntVar(&r.Active, "active", 1000, "number of active accounts in bank")
 	flag.Float64Var(&r.InitialBalance, "balance", 10000, "initial account balances")
+	flag.IntVar(&r.Workers, "workers", 1, "number of concurrent workers performing transfers")
+	flag.Float64Var(&r.Rate, "rate", 10, "number of transfers to start per second across all workers")
 	flag.Parse()
 
-	repo, err := selectRepo(*database, *url)
+	started := time.Now()
+
+	s, err := loadScenario(*scenarioPath, *expDuration)
+	if err != nil {
+		log.Fatalf("error loading scenario: %v", err)
+	}
+
+	if *targets != "" {
+		chaosOpts.Pods = strings.Split(*targets, ",")
+	}
+
+	if *dryRun {
+		chaosOpts.DryRun = true
+		render(s, chaosOpts)
+		return
+	}
+
+	// The workload connects through the proxies, so they must exist first,
+	// and leftover faults must be removed before it starts.
+	switch chaosOpts.Backend {
+	case runner.BackendToxiproxy:
+		if err = runner.CreateProxies(s.Proxy); err != nil {
+			log.Fatalf("error creating proxies: %v", err)
+		}
+	case runner.BackendProxy:
+		if chaosOpts.Proxies, err = runner.StartProxies(s.Proxy); err != nil {
+			log.Fatalf("error starting proxies: %v", err)
+		}
+	case runner.BackendLocal:
+		if err = runner.SweepLocal(s.Local); err != nil {
+			log.Fatalf("error removing faults left behind on local nodes: %v", err)
+		}
+	}
+
+	// Leave room in the pool for readiness and balance checks, which run
+	// alongside the workers.
+	repo, err := selectRepo(*database, *url, r.Workers+2)
 	if err != nil {
 		log.Fatalf("error selecting repo: %v", err)
 	}
 
 	notify := make(chan string, 1)
 
-	chaosRunner, err := runner.NewChaosRunner(repo, *ns, *chaosNS, *expDuration, *readyTimeout, notify)
+	chaosRunner, err := runner.NewChaosRunner(repo, s, chaosOpts, notify)
 	if err != nil {
 		log.Fatalf("error creating chaos runner: %v", err)
 	}
 
+	// Resolve the target pods before the workload starts, so that a
+	// selector that matches nothing fails the run straight away.
+	if _, err = chaosRunner.Pods(); err != nil {
+		log.Fatalf("error finding target pods: %v", err)
+	}
+
+	if *schedule != "" {
+		name, err := chaosRunner.SubmitSchedule(*schedule)
+		if err != nil {
+			log.Fatalf("error submitting schedule: %v", err)
+		}
+
+		log.Printf("submitted schedule %s", name)
+		return
+	}
+
+	// Remove any active chaos experiments if we're interrupted, then stop the
+	// workload so that the report is still written.
+	stop := make(chan struct{})
+	interrupted := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+		sig := <-signals
+		log.Printf("received %s, cleaning up chaos experiments", sig)
+
+		err := fmt.Errorf("interrupted by %s", sig)
+		if cleanupErr := chaosRunner.Cleanup(); cleanupErr != nil {
+			log.Printf("error cleaning up chaos experiments: %v", cleanupErr)
+			err = errors.Join(err, fmt.Errorf("cleaning up chaos experiments: %w", cleanupErr))
+		}
+
+		interrupted <- err
+		close(stop)
+	}()
+
 	// Run chaos runner on another thread so we don't block the workload.
+	chaosDone := make(chan error, 1)
 	go func() {
 		time.Sleep(time.Second * 10)
-		if err = chaosRunner.Run(); err != nil {
-			log.Fatalf("error running chaos experiments: %v", err)
+		err := chaosRunner.Run()
+		if err != nil {
+			log.Printf("error running chaos experiments: %v", err)
 		}
+		chaosDone <- err
 		close(notify)
 	}()
 
-	results, err := r.Run(repo, notify)
+	results, err := r.Run(repo, notify, stop)
 	if err != nil {
 		log.Fatalf("error running simulation: %v", err)
 	}
 
-	log.Printf("Total")
+	// The workload stops once the chaos runner finishes or the run is
+	// interrupted, in which case the chaos runner may still be waiting out
+	// an experiment.
+	var runErr error
+	select {
+	case runErr = <-interrupted:
+	default:
+		runErr = <-chaosDone
+	}
+
+	if *reportPath != "" {
+		run := report.Run{
+			RunID:          chaosRunner.RunID(),
+			Seed:           chaosRunner.Seed(),
+			Database:       *database,
+			Host:           report.Host(*url),
+			Namespace:      chaosOpts.Namespace,
+			ChaosNamespace: chaosOpts.ChaosNamespace,
+			Started:        started,
+			Finished:       time.Now(),
+			Flags:          flagValues(),
+		}
+		if runErr != nil {
+			run.Error = runErr.Error()
+		}
+
+		if err = report.New(run, results, chaosRunner.Timeline(), chaosRunner.Schedule()).Write(*reportPath); err != nil {
+			log.Fatalf("error writing report: %v", err)
+		}
+	}
+
+	log.Printf("Seed: %d", chaosRunner.Seed())
+
+	if schedule := chaosRunner.Schedule(); len(schedule) > 0 {
+		log.Printf("\nSchedule")
+		for _, step := range schedule {
+			log.Printf("\t%s", step)
+		}
+	}
+
+	log.Printf("\nTotal")
 	log.Printf("\terrors:   %d", results.TotalErrors)
 	log.Printf("\tdowntime: %s", results.TotalDowntime)
+	log.Printf("\tbehind:   %d", results.TotalBehind)
+
+	active := runner.ActiveDurations(chaosRunner.Timeline())
 
 	keys := lo.Keys(results.Stats)
 	sort.Strings(keys)
@@ -67,16 +215,115 @@ func main() {
 		log.Printf("\n%s", key)
 		log.Printf("\terrors:   %d", stats.ErrorCount)
 		log.Printf("\tdowntime: %s", stats.Downtime)
+		log.Printf("\tbehind:   %d (max lag %s)", stats.Behind, stats.MaxLag)
+		if key != runner.BaselineExperiment {
+			log.Printf("\tactive:   %s", active[key])
+			log.Printf("\trecovery: %s", stats.Recovery)
+			log.Printf("\tpool:     open=%d in-use=%d idle=%d waits=%d", stats.Pool.Open, stats.Pool.InUse, stats.Pool.Idle, stats.Pool.Waits)
+		}
+
+		latency := stats.Latency.Summary()
+		log.Printf("\tlatency:  p50=%s p90=%s p99=%s p99.9=%s max=%s (%d transfers)",
+			latency.P50, latency.P90, latency.P99, latency.P999, latency.Max, latency.Count)
+	}
+
+	restarts := lo.Filter(chaosRunner.Timeline(), func(e runner.Event, _ int) bool {
+		return len(e.Restarted) > 0
+	})
+	if len(restarts) > 0 {
+		log.Printf("\nRestarts")
+		for _, e := range restarts {
+			log.Printf("\t%s (%s): %v", e.Experiment, e.Name, e.Restarted)
+		}
+	}
+
+	nodeFaults := lo.Filter(chaosRunner.Timeline(), func(e runner.Event, _ int) bool {
+		return !e.Ready.IsZero()
+	})
+	if len(nodeFaults) > 0 {
+		log.Printf("\nNode recovery")
+		for _, e := range nodeFaults {
+			log.Printf("\t%s (%s): pods running after %s, database ready after %s", e.Experiment, e.Name, e.Running.Sub(e.Recovered), e.Ready.Sub(e.Recovered))
+		}
+	}
+
+	keys = lo.Keys(results.Invariants)
+	sort.Strings(keys)
+	log.Printf("\nBalances")
+	for _, key := range keys {
+		check := results.Invariants[key]
+		switch {
+		case check.Err != nil:
+			log.Printf("\t%s: error: %v", key, check.Err)
+		case !check.Ok():
+			log.Printf("\t%s: drift: %.2f (expected %.2f, got %.2f)", key, check.Drift, check.Expected, check.Actual)
+		default:
+			log.Printf("\t%s: ok", key)
+		}
+	}
+
+	if runErr != nil {
+		log.Fatalf("error running chaos experiments: %v", runErr)
+	}
+}
+
+func cleanup(args []string) {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	chaosNS := fs.String("chaos-namespace", "chaos-mesh", "chaos mesh namespace")
+	namespace := fs.String("namespace", "default", "database namespace, for faults injected by the kubernetes backend")
+	runID := fs.String("run-id", "", "only delete objects created by this run (defaults to all runs)")
+	fs.Parse(args)
+
+	deleted, err := runner.Sweep(*chaosNS, *namespace, *runID)
+	if err != nil {
+		log.Fatalf("error cleaning up chaos experiments: %v", err)
+	}
+
+	log.Printf("deleted %d chaos experiments", deleted)
+}
+
+func render(s scenario.Scenario, opts runner.ChaosOptions) {
+	chaosRunner, err := runner.NewChaosRunner(nil, s, opts, nil)
+	if err != nil {
+		log.Fatalf("error creating chaos runner: %v", err)
+	}
+
+	manifests, err := chaosRunner.Render()
+	if err != nil {
+		log.Fatalf("error rendering chaos experiments: %v", err)
+	}
+
+	os.Stdout.Write(manifests)
+}
+
+// flagValues returns the value of each flag, omitting the connection string
+// as it may contain credentials.
+func flagValues() map[string]string {
+	values := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name != "url" {
+			values[f.Name] = f.Value.String()
+		}
+	})
+
+	return values
+}
+
+func loadScenario(path string, duration time.Duration) (scenario.Scenario, error) {
+	if path == "" {
+		return scenario.Default(duration), nil
 	}
+
+	return scenario.Load(path, duration)
 }
 
-func selectRepo(database, url string) (repo.Repo, error) {
+func selectRepo(database, url string, maxConns int) (repo.Repo, error) {
 	switch strings.ToLower(database) {
 	case "oracle":
-		return repo.NewOracleRepo(url)
+		return repo.NewOracleRepo(url, maxConns)
 
 	case "postgres":
-		return repo.NewPostgresRepo(url)
+		return repo.NewPostgresRepo(url, maxConns)
 
 	default:
 		return nil, fmt.Errorf("unsupported database: %q", database)
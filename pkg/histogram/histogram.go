@@ -0,0 +1,118 @@
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits determines the precision of the histogram. Each power of two
+// is split into 2^(subBucketBits-1) linear sub-buckets, giving a worst case
+// error of under 1%.
+const subBucketBits = 8
+
+// Histogram records durations into log-linear buckets, in the style of
+// HdrHistogram, allowing percentiles to be calculated without storing every
+// value. Durations are recorded with microsecond resolution. A Histogram is
+// not safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	total  int64
+	max    time.Duration
+}
+
+// Summary holds the percentiles of interest for a Histogram.
+type Summary struct {
+	Count int64
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	P999  time.Duration
+	Max   time.Duration
+}
+
+func New() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a duration to the histogram.
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	i := bucketIndex(d.Microseconds())
+	if i >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, i-len(h.counts)+1)...)
+	}
+
+	h.counts[i]++
+	h.total++
+	h.max = max(h.max, d)
+}
+
+// Count returns the number of durations recorded.
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Max returns the largest duration recorded.
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the duration below which the given percentage (0-100)
+// of recorded durations fall.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	target := int64(math.Ceil(p / 100 * float64(h.total)))
+	target = min(max(target, 1), h.total)
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return min(time.Duration(bucketHighest(i))*time.Microsecond, h.max)
+		}
+	}
+
+	return h.max
+}
+
+// Summary returns the commonly reported percentiles for the histogram.
+func (h *Histogram) Summary() Summary {
+	return Summary{
+		Count: h.total,
+		P50:   h.Percentile(50),
+		P90:   h.Percentile(90),
+		P99:   h.Percentile(99),
+		P999:  h.Percentile(99.9),
+		Max:   h.max,
+	}
+}
+
+// bucketIndex returns the index of the bucket for v. Values below
+// 2^subBucketBits map directly onto a bucket, while larger values share a
+// bucket with values that have the same leading subBucketBits bits.
+func bucketIndex(v int64) int {
+	if v < 1<<subBucketBits {
+		return int(v)
+	}
+
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	return shift<<(subBucketBits-1) + int(v>>shift)
+}
+
+// bucketHighest returns the largest value that maps to the bucket at index i.
+func bucketHighest(i int) int64 {
+	if i < 1<<subBucketBits {
+		return int64(i)
+	}
+
+	shift := i>>(subBucketBits-1) - 1
+	sub := int64(i - shift<<(subBucketBits-1))
+	return (sub+1)<<shift - 1
+}
@@ -0,0 +1,146 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	cases := []struct {
+		name  string
+		value int64
+		index int
+	}{
+		{name: "zero", value: 0, index: 0},
+		{name: "below sub-bucket range", value: 255, index: 255},
+		{name: "first shared bucket", value: 256, index: 256},
+		{name: "shares bucket with previous", value: 257, index: 256},
+		{name: "next shared bucket", value: 258, index: 257},
+		{name: "larger power of two", value: 1000, index: 506},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := bucketIndex(c.value); got != c.index {
+				t.Fatalf("expected index %d, got %d", c.index, got)
+			}
+		})
+	}
+}
+
+func TestBucketHighest(t *testing.T) {
+	for _, v := range []int64{0, 1, 255, 256, 257, 1000, 50_000, 1_234_567, 60_000_000} {
+		highest := bucketHighest(bucketIndex(v))
+		if highest < v {
+			t.Fatalf("value %d: highest %d is below the value", v, highest)
+		}
+
+		if bucketIndex(highest) != bucketIndex(v) {
+			t.Fatalf("value %d: highest %d falls in a different bucket", v, highest)
+		}
+
+		if bucketIndex(highest+1) != bucketIndex(v)+1 {
+			t.Fatalf("value %d: %d does not start the next bucket", v, highest+1)
+		}
+
+		if err := float64(highest-v) / float64(max(v, 1)); err >= 0.01 {
+			t.Fatalf("value %d: error %.4f exceeds 1%%", v, err)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	h := New()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	cases := []struct {
+		name       string
+		percentile float64
+		exp        time.Duration
+	}{
+		{name: "minimum", percentile: 0, exp: time.Millisecond},
+		{name: "p50", percentile: 50, exp: 500 * time.Millisecond},
+		{name: "p90", percentile: 90, exp: 900 * time.Millisecond},
+		{name: "p99", percentile: 99, exp: 990 * time.Millisecond},
+		{name: "p99.9", percentile: 99.9, exp: 999 * time.Millisecond},
+		{name: "maximum", percentile: 100, exp: time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act := h.Percentile(c.percentile)
+			if act < c.exp || float64(act-c.exp) >= 0.01*float64(c.exp) {
+				t.Fatalf("expected %s (within 1%%), got %s", c.exp, act)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []time.Duration
+		exp    Summary
+	}{
+		{
+			name: "empty",
+			exp:  Summary{},
+		},
+		{
+			name:   "single value",
+			values: []time.Duration{42 * time.Microsecond},
+			exp: Summary{
+				Count: 1,
+				P50:   42 * time.Microsecond,
+				P90:   42 * time.Microsecond,
+				P99:   42 * time.Microsecond,
+				P999:  42 * time.Microsecond,
+				Max:   42 * time.Microsecond,
+			},
+		},
+		{
+			name:   "percentiles report bucket highest",
+			values: []time.Duration{time.Millisecond, time.Millisecond, 5 * time.Millisecond},
+			exp: Summary{
+				Count: 3,
+				P50:   1003 * time.Microsecond,
+				P90:   5 * time.Millisecond,
+				P99:   5 * time.Millisecond,
+				P999:  5 * time.Millisecond,
+				Max:   5 * time.Millisecond,
+			},
+		},
+		{
+			name:   "percentiles capped at max",
+			values: []time.Duration{1001 * time.Microsecond},
+			exp: Summary{
+				Count: 1,
+				P50:   1001 * time.Microsecond,
+				P90:   1001 * time.Microsecond,
+				P99:   1001 * time.Microsecond,
+				P999:  1001 * time.Microsecond,
+				Max:   1001 * time.Microsecond,
+			},
+		},
+		{
+			name:   "negative durations recorded as zero",
+			values: []time.Duration{-time.Second},
+			exp:    Summary{Count: 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := New()
+			for _, v := range c.values {
+				h.Record(v)
+			}
+
+			if act := h.Summary(); act != c.exp {
+				t.Fatalf("expected %+v, got %+v", c.exp, act)
+			}
+		})
+	}
+}
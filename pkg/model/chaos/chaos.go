@@ -0,0 +1,45 @@
+package chaos
+
+// Object is implemented by all Chaos Mesh experiments.
+type Object interface {
+	GetMetadata() *Metadata
+	GetKind() string
+	GetSpec() any
+}
+
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type Selector struct {
+	Namespaces     []string            `yaml:"namespaces"`
+	LabelSelectors LabelSelectors      `yaml:"labelSelectors,omitempty"`
+	Pods           map[string][]string `yaml:"pods,omitempty"`
+}
+
+type LabelSelectors struct {
+	StatefulsetKubernetesIoPodName string `yaml:"statefulset.kubernetes.io/pod-name,omitempty"`
+}
+
+// podSelector returns a selector that matches the given pods, using the
+// StatefulSet pod name label where only one pod is being selected.
+func podSelector(podNS string, pods ...string) Selector {
+	if len(pods) == 1 {
+		return Selector{
+			Namespaces: []string{podNS},
+			LabelSelectors: LabelSelectors{
+				StatefulsetKubernetesIoPodName: pods[0],
+			},
+		}
+	}
+
+	return Selector{
+		Namespaces: []string{podNS},
+		Pods: map[string][]string{
+			podNS: pods,
+		},
+	}
+}
@@ -1,7 +1,6 @@
 package chaos
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -13,40 +12,102 @@ type DiskChaos struct {
 }
 
 type DiskSpec struct {
-	Action     string   `yaml:"action"`
-	Mode       string   `yaml:"mode"`
-	Selector   Selector `yaml:"selector"`
-	VolumePath string   `yaml:"volumePath"`
-	Path       string   `yaml:"path"`
-	Delay      string   `yaml:"delay"`
-	Percent    int      `yaml:"percent"`
-	Duration   string   `yaml:"duration"`
+	Action     string        `yaml:"action"`
+	Mode       string        `yaml:"mode"`
+	Selector   Selector      `yaml:"selector"`
+	VolumePath string        `yaml:"volumePath"`
+	Path       string        `yaml:"path,omitempty"`
+	Methods    []string      `yaml:"methods,omitempty"`
+	Delay      string        `yaml:"delay,omitempty"`
+	Errno      int           `yaml:"errno,omitempty"`
+	Attr       *AttrOverride `yaml:"attr,omitempty"`
+	Mistake    *Mistake      `yaml:"mistake,omitempty"`
+	Percent    int           `yaml:"percent"`
+	Duration   string        `yaml:"duration"`
 }
 
-func MakeDiskLatency(pod, podNS, chaosNS, volume, path string, percent int, latency time.Duration) DiskChaos {
-	return makeDiskChaos(pod, podNS, chaosNS, "latency", volume, path, percent)
+// DiskTarget describes the files and operations affected by disk chaos.
+type DiskTarget struct {
+	VolumePath string
+	Path       string
+	Methods    []string
+	Percent    int
 }
 
-func makeDiskChaos(pod, podNS, chaosNS, action, volume, path string, percent int) DiskChaos {
+// AttrOverride overrides the attributes returned for matching files. Only
+// the attributes that are set are overridden.
+type AttrOverride struct {
+	Ino    *uint64 `yaml:"ino,omitempty"`
+	Size   *uint64 `yaml:"size,omitempty"`
+	Blocks *uint64 `yaml:"blocks,omitempty"`
+	Kind   string  `yaml:"kind,omitempty"`
+	Perm   *uint16 `yaml:"perm,omitempty"`
+	Nlink  *uint32 `yaml:"nlink,omitempty"`
+	UID    *uint32 `yaml:"uid,omitempty"`
+	GID    *uint32 `yaml:"gid,omitempty"`
+	Rdev   *uint32 `yaml:"rdev,omitempty"`
+}
+
+// Mistake corrupts the data read from or written to matching files.
+type Mistake struct {
+	Filling        string `yaml:"filling"`
+	MaxOccurrences int    `yaml:"maxOccurrences"`
+	MaxLength      int    `yaml:"maxLength"`
+}
+
+func (c *DiskChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *DiskChaos) GetKind() string {
+	return c.Kind
+}
+
+func (c *DiskChaos) GetSpec() any {
+	return c.Spec
+}
+
+func MakeDiskLatency(name string, pods []string, podNS, chaosNS string, target DiskTarget, latency, duration time.Duration) DiskChaos {
+	c := makeDiskChaos(name, pods, podNS, chaosNS, "latency", target, duration)
+	c.Spec.Delay = latency.String()
+	return c
+}
+
+func MakeDiskFault(name string, pods []string, podNS, chaosNS string, target DiskTarget, errno int, duration time.Duration) DiskChaos {
+	c := makeDiskChaos(name, pods, podNS, chaosNS, "fault", target, duration)
+	c.Spec.Errno = errno
+	return c
+}
+
+func MakeDiskAttrOverride(name string, pods []string, podNS, chaosNS string, target DiskTarget, attr AttrOverride, duration time.Duration) DiskChaos {
+	c := makeDiskChaos(name, pods, podNS, chaosNS, "attrOverride", target, duration)
+	c.Spec.Attr = &attr
+	return c
+}
+
+func MakeDiskMistake(name string, pods []string, podNS, chaosNS string, target DiskTarget, mistake Mistake, duration time.Duration) DiskChaos {
+	c := makeDiskChaos(name, pods, podNS, chaosNS, "mistake", target, duration)
+	c.Spec.Mistake = &mistake
+	return c
+}
+
+func makeDiskChaos(name string, pods []string, podNS, chaosNS, action string, target DiskTarget, duration time.Duration) DiskChaos {
 	return DiskChaos{
 		APIVersion: "chaos-mesh.org/v1alpha1",
 		Kind:       "IOChaos",
 		Metadata: Metadata{
-			Name:      fmt.Sprintf("%s-%s", pod, action),
+			Name:      name,
 			Namespace: chaosNS,
 		},
 		Spec: DiskSpec{
-			Action: action,
-			Mode:   "one",
-			Selector: Selector{
-				Namespaces: []string{podNS},
-				LabelSelectors: LabelSelectors{
-					StatefulsetKubernetesIoPodName: pod,
-				},
-			},
-			VolumePath: volume,
-			Path:       path,
-			Percent:    percent,
+			Action:     action,
+			Mode:       "all",
+			Selector:   podSelector(podNS, pods...),
+			VolumePath: target.VolumePath,
+			Path:       target.Path,
+			Methods:    target.Methods,
+			Percent:    target.Percent,
+			Duration:   duration.String(),
 		},
 	}
 }
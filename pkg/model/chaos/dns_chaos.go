@@ -0,0 +1,53 @@
+package chaos
+
+import (
+	"time"
+)
+
+type DNSChaos struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       DNSSpec  `yaml:"spec"`
+}
+
+type DNSSpec struct {
+	Action   string   `yaml:"action"`
+	Mode     string   `yaml:"mode"`
+	Selector Selector `yaml:"selector"`
+	Patterns []string `yaml:"patterns,omitempty"`
+	Duration string   `yaml:"duration"`
+}
+
+func (c *DNSChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *DNSChaos) GetKind() string {
+	return c.Kind
+}
+
+func (c *DNSChaos) GetSpec() any {
+	return c.Spec
+}
+
+// MakeDNSChaos returns a DNSChaos that causes DNS lookups made by the given
+// pods for domains matching patterns (or all domains if no patterns are
+// provided) to either fail ("error") or return random IPs ("random").
+func MakeDNSChaos(name string, pods []string, podNS, chaosNS, action string, patterns []string, duration time.Duration) DNSChaos {
+	return DNSChaos{
+		APIVersion: "chaos-mesh.org/v1alpha1",
+		Kind:       "DNSChaos",
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: chaosNS,
+		},
+		Spec: DNSSpec{
+			Action:   action,
+			Mode:     "all",
+			Selector: podSelector(podNS, pods...),
+			Patterns: patterns,
+			Duration: duration.String(),
+		},
+	}
+}
@@ -1,9 +1,5 @@
 package chaos
 
-import (
-	"fmt"
-)
-
 type NetworkChaos struct {
 	APIVersion string      `yaml:"apiVersion"`
 	Kind       string      `yaml:"kind"`
@@ -12,11 +8,16 @@ type NetworkChaos struct {
 }
 
 type NetworkSpec struct {
-	Action    string   `yaml:"action"`
-	Mode      string   `yaml:"mode"`
-	Selector  Selector `yaml:"selector"`
-	Direction string   `yaml:"direction"`
-	Target    Target   `yaml:"target"`
+	Action    string     `yaml:"action"`
+	Mode      string     `yaml:"mode"`
+	Selector  Selector   `yaml:"selector"`
+	Direction string     `yaml:"direction,omitempty"`
+	Target    *Target    `yaml:"target,omitempty"`
+	Delay     *Delay     `yaml:"delay,omitempty"`
+	Loss      *Loss      `yaml:"loss,omitempty"`
+	Duplicate *Duplicate `yaml:"duplicate,omitempty"`
+	Corrupt   *Corrupt   `yaml:"corrupt,omitempty"`
+	Bandwidth *Bandwidth `yaml:"bandwidth,omitempty"`
 }
 
 type Target struct {
@@ -24,33 +25,105 @@ type Target struct {
 	Selector Selector `yaml:"selector"`
 }
 
-func MakePartition(sourcePod, targetPod, podNS, chaosNS, direction string) NetworkChaos {
-	return NetworkChaos{
+type Delay struct {
+	Latency     string `yaml:"latency"`
+	Jitter      string `yaml:"jitter,omitempty"`
+	Correlation string `yaml:"correlation,omitempty"`
+}
+
+type Loss struct {
+	Loss        string `yaml:"loss"`
+	Correlation string `yaml:"correlation,omitempty"`
+}
+
+type Duplicate struct {
+	Duplicate   string `yaml:"duplicate"`
+	Correlation string `yaml:"correlation,omitempty"`
+}
+
+type Corrupt struct {
+	Corrupt     string `yaml:"corrupt"`
+	Correlation string `yaml:"correlation,omitempty"`
+}
+
+type Bandwidth struct {
+	Rate   string `yaml:"rate"`
+	Limit  uint32 `yaml:"limit"`
+	Buffer uint32 `yaml:"buffer"`
+}
+
+func (c *NetworkChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *NetworkChaos) GetKind() string {
+	return c.Kind
+}
+
+func (c *NetworkChaos) GetSpec() any {
+	return c.Spec
+}
+
+// MakePartition returns a NetworkChaos that partitions one group of pods
+// from another.
+func MakePartition(name string, pods, targets []string, podNS, chaosNS, direction string) NetworkChaos {
+	return makeNetworkChaos(name, pods, targets, podNS, chaosNS, "partition", direction)
+}
+
+func MakeNetworkDelay(name string, pods, targets []string, podNS, chaosNS, direction string, delay Delay) NetworkChaos {
+	c := makeNetworkChaos(name, pods, targets, podNS, chaosNS, "delay", direction)
+	c.Spec.Delay = &delay
+	return c
+}
+
+func MakeNetworkLoss(name string, pods, targets []string, podNS, chaosNS, direction string, loss Loss) NetworkChaos {
+	c := makeNetworkChaos(name, pods, targets, podNS, chaosNS, "loss", direction)
+	c.Spec.Loss = &loss
+	return c
+}
+
+func MakeNetworkDuplicate(name string, pods, targets []string, podNS, chaosNS, direction string, duplicate Duplicate) NetworkChaos {
+	c := makeNetworkChaos(name, pods, targets, podNS, chaosNS, "duplicate", direction)
+	c.Spec.Duplicate = &duplicate
+	return c
+}
+
+func MakeNetworkCorrupt(name string, pods, targets []string, podNS, chaosNS, direction string, corrupt Corrupt) NetworkChaos {
+	c := makeNetworkChaos(name, pods, targets, podNS, chaosNS, "corrupt", direction)
+	c.Spec.Corrupt = &corrupt
+	return c
+}
+
+func MakeNetworkBandwidth(name string, pods, targets []string, podNS, chaosNS, direction string, bandwidth Bandwidth) NetworkChaos {
+	c := makeNetworkChaos(name, pods, targets, podNS, chaosNS, "bandwidth", direction)
+	c.Spec.Bandwidth = &bandwidth
+	return c
+}
+
+// makeNetworkChaos returns a NetworkChaos that affects traffic from pods. If
+// targets are provided, only traffic between pods and targets is affected.
+func makeNetworkChaos(name string, pods, targets []string, podNS, chaosNS, action, direction string) NetworkChaos {
+	c := NetworkChaos{
 		APIVersion: "chaos-mesh.org/v1alpha1",
 		Kind:       "NetworkChaos",
 		Metadata: Metadata{
-			Name:      fmt.Sprintf("%s-%s", sourcePod, targetPod),
+			Name:      name,
 			Namespace: chaosNS,
 		},
 		Spec: NetworkSpec{
-			Action:    "partition",
+			Action:    action,
 			Mode:      "all",
 			Direction: direction,
-			Selector: Selector{
-				Namespaces: []string{podNS},
-				LabelSelectors: LabelSelectors{
-					StatefulsetKubernetesIoPodName: sourcePod,
-				},
-			},
-			Target: Target{
-				Mode: "one",
-				Selector: Selector{
-					Namespaces: []string{podNS},
-					LabelSelectors: LabelSelectors{
-						StatefulsetKubernetesIoPodName: targetPod,
-					},
-				},
-			},
+			Selector:  podSelector(podNS, pods...),
 		},
 	}
+
+	if len(targets) > 0 {
+		c.Spec.Target = &Target{
+			Mode:     "all",
+			Selector: podSelector(podNS, targets...),
+		}
+	}
+
+	return c
 }
@@ -1,7 +1,6 @@
 package chaos
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -12,11 +11,6 @@ type PodChaos struct {
 	Spec       PodSpec  `yaml:"spec"`
 }
 
-type Metadata struct {
-	Name      string `yaml:"name"`
-	Namespace string `yaml:"namespace"`
-}
-
 type PodSpec struct {
 	Action   string        `yaml:"action"`
 	Mode     string        `yaml:"mode"`
@@ -24,33 +18,31 @@ type PodSpec struct {
 	Selector Selector      `yaml:"selector"`
 }
 
-type Selector struct {
-	Namespaces     []string       `yaml:"namespaces"`
-	LabelSelectors LabelSelectors `yaml:"labelSelectors"`
+func (c *PodChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *PodChaos) GetKind() string {
+	return c.Kind
 }
 
-type LabelSelectors struct {
-	StatefulsetKubernetesIoPodName string `yaml:"statefulset.kubernetes.io/pod-name"`
+func (c *PodChaos) GetSpec() any {
+	return c.Spec
 }
 
-func MakePodChaos(pod, podNS, chaosNS, action string, duration time.Duration) PodChaos {
+func MakePodChaos(name string, pods []string, podNS, chaosNS, action string, duration time.Duration) PodChaos {
 	return PodChaos{
 		APIVersion: "chaos-mesh.org/v1alpha1",
 		Kind:       "PodChaos",
 		Metadata: Metadata{
-			Name:      fmt.Sprintf("%s-%s", pod, action),
+			Name:      name,
 			Namespace: chaosNS,
 		},
 		Spec: PodSpec{
 			Action:   action,
-			Mode:     "one",
+			Mode:     "all",
 			Duration: duration,
-			Selector: Selector{
-				Namespaces: []string{podNS},
-				LabelSelectors: LabelSelectors{
-					StatefulsetKubernetesIoPodName: pod,
-				},
-			},
+			Selector: podSelector(podNS, pods...),
 		},
 	}
 }
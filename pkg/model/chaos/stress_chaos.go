@@ -0,0 +1,63 @@
+package chaos
+
+import (
+	"time"
+)
+
+type StressChaos struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   Metadata   `yaml:"metadata"`
+	Spec       StressSpec `yaml:"spec"`
+}
+
+type StressSpec struct {
+	Mode      string    `yaml:"mode"`
+	Selector  Selector  `yaml:"selector"`
+	Stressors Stressors `yaml:"stressors"`
+	Duration  string    `yaml:"duration"`
+}
+
+type Stressors struct {
+	CPU    *CPUStressor    `yaml:"cpu,omitempty"`
+	Memory *MemoryStressor `yaml:"memory,omitempty"`
+}
+
+type CPUStressor struct {
+	Workers int `yaml:"workers"`
+	Load    int `yaml:"load"`
+}
+
+type MemoryStressor struct {
+	Workers int    `yaml:"workers"`
+	Size    string `yaml:"size"`
+}
+
+func (c *StressChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *StressChaos) GetKind() string {
+	return c.Kind
+}
+
+func (c *StressChaos) GetSpec() any {
+	return c.Spec
+}
+
+func MakeStressChaos(name string, pods []string, podNS, chaosNS string, stressors Stressors, duration time.Duration) StressChaos {
+	return StressChaos{
+		APIVersion: "chaos-mesh.org/v1alpha1",
+		Kind:       "StressChaos",
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: chaosNS,
+		},
+		Spec: StressSpec{
+			Mode:      "all",
+			Selector:  podSelector(podNS, pods...),
+			Stressors: stressors,
+			Duration:  duration.String(),
+		},
+	}
+}
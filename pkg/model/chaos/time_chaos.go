@@ -0,0 +1,52 @@
+package chaos
+
+import (
+	"time"
+)
+
+type TimeChaos struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       TimeSpec `yaml:"spec"`
+}
+
+type TimeSpec struct {
+	Mode       string   `yaml:"mode"`
+	Selector   Selector `yaml:"selector"`
+	TimeOffset string   `yaml:"timeOffset"`
+	ClockIDs   []string `yaml:"clockIds,omitempty"`
+	Duration   string   `yaml:"duration"`
+}
+
+func (c *TimeChaos) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *TimeChaos) GetKind() string {
+	return c.Kind
+}
+
+func (c *TimeChaos) GetSpec() any {
+	return c.Spec
+}
+
+// MakeTimeChaos returns a TimeChaos that shifts the clocks of the given pods
+// by offset, which may be negative.
+func MakeTimeChaos(name string, pods []string, podNS, chaosNS string, offset time.Duration, clockIDs []string, duration time.Duration) TimeChaos {
+	return TimeChaos{
+		APIVersion: "chaos-mesh.org/v1alpha1",
+		Kind:       "TimeChaos",
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: chaosNS,
+		},
+		Spec: TimeSpec{
+			Mode:       "all",
+			Selector:   podSelector(podNS, pods...),
+			TimeOffset: offset.String(),
+			ClockIDs:   clockIDs,
+			Duration:   duration.String(),
+		},
+	}
+}
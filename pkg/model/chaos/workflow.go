@@ -0,0 +1,161 @@
+package chaos
+
+import (
+	"fmt"
+	"time"
+)
+
+// Workflow template types that aren't chaos experiments.
+const (
+	TemplateSerial   = "Serial"
+	TemplateParallel = "Parallel"
+	TemplateSuspend  = "Suspend"
+)
+
+type Workflow struct {
+	APIVersion string       `yaml:"apiVersion"`
+	Kind       string       `yaml:"kind"`
+	Metadata   Metadata     `yaml:"metadata"`
+	Spec       WorkflowSpec `yaml:"spec"`
+}
+
+type WorkflowSpec struct {
+	Entry     string     `yaml:"entry"`
+	Templates []Template `yaml:"templates"`
+}
+
+// Template is a step in a workflow. Serial and Parallel templates run their
+// children, Suspend templates wait for their deadline, and chaos templates
+// inject the embedded chaos until their deadline.
+type Template struct {
+	Name         string   `yaml:"name"`
+	TemplateType string   `yaml:"templateType"`
+	Deadline     string   `yaml:"deadline,omitempty"`
+	Children     []string `yaml:"children,omitempty"`
+
+	PodChaos     any `yaml:"podChaos,omitempty"`
+	NetworkChaos any `yaml:"networkChaos,omitempty"`
+	IOChaos      any `yaml:"ioChaos,omitempty"`
+	TimeChaos    any `yaml:"timeChaos,omitempty"`
+	StressChaos  any `yaml:"stressChaos,omitempty"`
+	DNSChaos     any `yaml:"dnsChaos,omitempty"`
+}
+
+func (c *Workflow) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *Workflow) GetKind() string {
+	return c.Kind
+}
+
+func (c *Workflow) GetSpec() any {
+	return c.Spec
+}
+
+func MakeWorkflow(name, chaosNS string, spec WorkflowSpec) Workflow {
+	return Workflow{
+		APIVersion: "chaos-mesh.org/v1alpha1",
+		Kind:       "Workflow",
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: chaosNS,
+		},
+		Spec: spec,
+	}
+}
+
+// MakeGroupTemplate returns a Serial or Parallel template.
+func MakeGroupTemplate(name, templateType string, children []string) Template {
+	return Template{
+		Name:         name,
+		TemplateType: templateType,
+		Children:     children,
+	}
+}
+
+func MakeSuspendTemplate(name string, deadline time.Duration) Template {
+	return Template{
+		Name:         name,
+		TemplateType: TemplateSuspend,
+		Deadline:     deadline.String(),
+	}
+}
+
+// MakeChaosTemplate returns a template that injects the given chaos until
+// the deadline has passed.
+func MakeChaosTemplate(name string, obj Object, deadline time.Duration) (Template, error) {
+	t := Template{
+		Name:         name,
+		TemplateType: obj.GetKind(),
+		Deadline:     deadline.String(),
+	}
+
+	switch obj.GetKind() {
+	case "PodChaos":
+		t.PodChaos = obj.GetSpec()
+	case "NetworkChaos":
+		t.NetworkChaos = obj.GetSpec()
+	case "IOChaos":
+		t.IOChaos = obj.GetSpec()
+	case "TimeChaos":
+		t.TimeChaos = obj.GetSpec()
+	case "StressChaos":
+		t.StressChaos = obj.GetSpec()
+	case "DNSChaos":
+		t.DNSChaos = obj.GetSpec()
+	default:
+		return Template{}, fmt.Errorf("unsupported workflow chaos kind: %q", obj.GetKind())
+	}
+
+	return t, nil
+}
+
+// Schedule runs a workflow on a cron schedule.
+type Schedule struct {
+	APIVersion string       `yaml:"apiVersion"`
+	Kind       string       `yaml:"kind"`
+	Metadata   Metadata     `yaml:"metadata"`
+	Spec       ScheduleSpec `yaml:"spec"`
+}
+
+type ScheduleSpec struct {
+	Schedule          string       `yaml:"schedule"`
+	HistoryLimit      int          `yaml:"historyLimit"`
+	ConcurrencyPolicy string       `yaml:"concurrencyPolicy"`
+	Type              string       `yaml:"type"`
+	Workflow          WorkflowSpec `yaml:"workflow"`
+}
+
+func (c *Schedule) GetMetadata() *Metadata {
+	return &c.Metadata
+}
+
+func (c *Schedule) GetKind() string {
+	return c.Kind
+}
+
+func (c *Schedule) GetSpec() any {
+	return c.Spec
+}
+
+// MakeSchedule returns a schedule that runs a workflow at the times given by
+// a cron expression (e.g. "@every 6h"), skipping runs while the previous one
+// is still in progress.
+func MakeSchedule(name, chaosNS, cron string, workflow WorkflowSpec) Schedule {
+	return Schedule{
+		APIVersion: "chaos-mesh.org/v1alpha1",
+		Kind:       "Schedule",
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: chaosNS,
+		},
+		Spec: ScheduleSpec{
+			Schedule:          cron,
+			HistoryLimit:      3,
+			ConcurrencyPolicy: "Forbid",
+			Type:              "Workflow",
+			Workflow:          workflow,
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+var clockIDs = []string{
+	"CLOCK_REALTIME", "CLOCK_MONOTONIC", "CLOCK_PROCESS_CPUTIME_ID",
+	"CLOCK_THREAD_CPUTIME_ID", "CLOCK_MONOTONIC_RAW", "CLOCK_REALTIME_COARSE",
+	"CLOCK_MONOTONIC_COARSE", "CLOCK_BOOTTIME", "CLOCK_REALTIME_ALARM",
+	"CLOCK_BOOTTIME_ALARM",
+}
+
+// Clock describes how to skew the clocks of target pods.
+type Clock struct {
+	// Offsets are applied one at a time and may be negative to move the
+	// clock backwards.
+	Offsets []time.Duration `yaml:"offsets"`
+
+	// ClockIDs are the clocks to skew. Defaults to CLOCK_REALTIME.
+	ClockIDs []string `yaml:"clockIds"`
+}
+
+func (e Experiment) validateClock() []error {
+	if e.Clock == nil {
+		return []error{fmt.Errorf("clock is required")}
+	}
+
+	var errs []error
+
+	if len(e.Clock.Offsets) == 0 {
+		errs = append(errs, fmt.Errorf("clock offsets are required"))
+	}
+	if lo.Contains(e.Clock.Offsets, 0) {
+		errs = append(errs, fmt.Errorf("clock offsets must not be zero"))
+	}
+
+	if invalid := lo.Without(e.Clock.ClockIDs, clockIDs...); len(invalid) > 0 {
+		errs = append(errs, fmt.Errorf("invalid clock ids %v, expected any of %v", invalid, clockIDs))
+	}
+
+	return errs
+}
+
+func (c *Clock) setDefaults() {
+	if len(c.ClockIDs) == 0 {
+		c.ClockIDs = []string{"CLOCK_REALTIME"}
+	}
+}
@@ -0,0 +1,37 @@
+package scenario
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Experiment types that can't run as part of a concurrent group, as they
+// record their results separately.
+var nonConcurrentTypes = []string{TypeConcurrent, TypeClockSkew}
+
+func (e Experiment) validateConcurrent() []error {
+	if len(e.Experiments) < 2 {
+		return []error{fmt.Errorf("at least 2 experiments are required")}
+	}
+
+	var errs []error
+	for i, exp := range e.Experiments {
+		childErrs := exp.validate()
+
+		if slices.Contains(nonConcurrentTypes, exp.Type) {
+			childErrs = append(childErrs, fmt.Errorf("%s experiments can't run concurrently", exp.Type))
+		}
+		if exp.Domain != "" {
+			childErrs = append(childErrs, fmt.Errorf("domain is not supported for concurrent experiments"))
+		}
+		if exp.Start < 0 {
+			childErrs = append(childErrs, fmt.Errorf("start must not be negative"))
+		}
+
+		for _, err := range childErrs {
+			errs = append(errs, fmt.Errorf("experiment %d (%s): %w", i+1, exp.Name, err))
+		}
+	}
+
+	return errs
+}
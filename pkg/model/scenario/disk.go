@@ -0,0 +1,134 @@
+package scenario
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Filesystem methods that disk chaos can be limited to.
+var diskMethods = []string{
+	"lookup", "forget", "getattr", "setattr", "readlink", "mknod", "mkdir",
+	"unlink", "rmdir", "symlink", "rename", "link", "open", "read", "write",
+	"flush", "release", "fsync", "opendir", "readdir", "releasedir",
+	"fsyncdir", "statfs", "setxattr", "getxattr", "listxattr", "removexattr",
+	"access", "create", "getlk", "setlk", "bmap",
+}
+
+var diskFillings = []string{"zero", "random"}
+
+// Disk describes the files affected by disk chaos and how they're affected.
+type Disk struct {
+	// VolumePath is the mount point of the volume to inject faults into.
+	VolumePath string `yaml:"volumePath"`
+
+	// Path is a glob of the files to affect. Defaults to all files in the
+	// volume.
+	Path string `yaml:"path"`
+
+	// Percent is the percentage of operations to affect. Defaults to 100.
+	Percent int `yaml:"percent"`
+
+	// Methods limits the chaos to the given filesystem operations.
+	Methods []string `yaml:"methods"`
+
+	Latency time.Duration `yaml:"latency"`
+	Errno   int           `yaml:"errno"`
+	Attr    *DiskAttr     `yaml:"attr"`
+	Mistake *DiskMistake  `yaml:"mistake"`
+}
+
+// DiskAttr overrides the attributes of files. Only the attributes that are
+// set are overridden.
+type DiskAttr struct {
+	Ino    *uint64 `yaml:"ino"`
+	Size   *uint64 `yaml:"size"`
+	Blocks *uint64 `yaml:"blocks"`
+	Kind   string  `yaml:"kind"`
+	Perm   *uint16 `yaml:"perm"`
+	Nlink  *uint32 `yaml:"nlink"`
+	UID    *uint32 `yaml:"uid"`
+	GID    *uint32 `yaml:"gid"`
+	Rdev   *uint32 `yaml:"rdev"`
+}
+
+// DiskMistake corrupts data read from or written to files.
+type DiskMistake struct {
+	// Filling is the data to replace the original data with: zero or random.
+	Filling        string `yaml:"filling"`
+	MaxOccurrences int    `yaml:"maxOccurrences"`
+	MaxLength      int    `yaml:"maxLength"`
+}
+
+// IsDisk returns true if the experiment injects disk faults.
+func (e Experiment) IsDisk() bool {
+	switch e.Type {
+	case TypeDiskLatency, TypeDiskFault, TypeDiskAttrOverride, TypeDiskMistake:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e Experiment) validateDisk() []error {
+	if e.Disk == nil {
+		return []error{fmt.Errorf("disk is required")}
+	}
+
+	var errs []error
+	d := e.Disk
+
+	if d.VolumePath == "" {
+		errs = append(errs, fmt.Errorf("disk volumePath is required"))
+	}
+
+	if d.Percent < 0 || d.Percent > 100 {
+		errs = append(errs, fmt.Errorf("disk percent must be between 0 and 100"))
+	}
+
+	if invalid := lo.Without(d.Methods, diskMethods...); len(invalid) > 0 {
+		errs = append(errs, fmt.Errorf("invalid disk methods %v", invalid))
+	}
+
+	switch e.Type {
+	case TypeDiskLatency:
+		if d.Latency <= 0 {
+			errs = append(errs, fmt.Errorf("disk latency must be greater than zero"))
+		}
+
+	case TypeDiskFault:
+		if d.Errno <= 0 {
+			errs = append(errs, fmt.Errorf("disk errno must be greater than zero"))
+		}
+
+	case TypeDiskAttrOverride:
+		if d.Attr == nil || *d.Attr == (DiskAttr{}) {
+			errs = append(errs, fmt.Errorf("disk attr must override at least one attribute"))
+		}
+
+	case TypeDiskMistake:
+		if d.Mistake == nil {
+			errs = append(errs, fmt.Errorf("disk mistake is required"))
+			break
+		}
+		if !slices.Contains(diskFillings, d.Mistake.Filling) {
+			errs = append(errs, fmt.Errorf("invalid disk mistake filling %q, expected one of %v", d.Mistake.Filling, diskFillings))
+		}
+		if d.Mistake.MaxOccurrences <= 0 {
+			errs = append(errs, fmt.Errorf("disk mistake maxOccurrences must be greater than zero"))
+		}
+		if d.Mistake.MaxLength <= 0 {
+			errs = append(errs, fmt.Errorf("disk mistake maxLength must be greater than zero"))
+		}
+	}
+
+	return errs
+}
+
+func (d *Disk) setDefaults() {
+	if d.Percent == 0 {
+		d.Percent = 100
+	}
+}
@@ -0,0 +1,31 @@
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Chaos Mesh only supports wildcards at the end of a pattern.
+var dnsPatternRegex = regexp.MustCompile(`^[a-zA-Z0-9.\-]*[*?]?$`)
+
+// DNS describes the domains affected by DNS chaos.
+type DNS struct {
+	// Patterns are the domains to affect (e.g. cockroachdb-*). Defaults to
+	// all domains.
+	Patterns []string `yaml:"patterns"`
+}
+
+func (e Experiment) validateDNS() []error {
+	if e.DNS == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, p := range e.DNS.Patterns {
+		if p == "" || !dnsPatternRegex.MatchString(p) {
+			errs = append(errs, fmt.Errorf("invalid dns pattern %q, wildcards are only supported at the end of a pattern", p))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,39 @@
+package scenario
+
+import "fmt"
+
+// Scale describes the size to scale the database's StatefulSet down to.
+type Scale struct {
+	// Replicas is the number of pods to leave running.
+	Replicas int `yaml:"replicas"`
+}
+
+func (e Experiment) validateScale() []error {
+	if e.Scale == nil {
+		return []error{fmt.Errorf("scale is required")}
+	}
+
+	if e.Scale.Replicas < 0 {
+		return []error{fmt.Errorf("scale replicas must not be negative")}
+	}
+
+	return nil
+}
+
+// Drain configures how a node is drained.
+type Drain struct {
+	// Force deletes pods rather than evicting them, bypassing any
+	// PodDisruptionBudgets covering them.
+	Force bool `yaml:"force"`
+}
+
+// IsNodeFault returns true if the experiment takes down the nodes the
+// targets are scheduled on, rather than the targets themselves.
+func (e Experiment) IsNodeFault() bool {
+	switch e.Type {
+	case TypeNodeDrain, TypeNodeCordon, TypeKubeletStop:
+		return true
+	default:
+		return false
+	}
+}
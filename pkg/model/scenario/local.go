@@ -0,0 +1,75 @@
+package scenario
+
+import "fmt"
+
+// Local describes database nodes running as local processes or Docker
+// containers, for the local backend.
+type Local struct {
+	Nodes []LocalNode `yaml:"nodes"`
+}
+
+// LocalNode is a database node running as a Docker container or a local
+// process. Node names are used in place of pod names for targets and peers.
+type LocalNode struct {
+	Name string `yaml:"name"`
+
+	// Container is the name or ID of the Docker container running the
+	// node.
+	Container string `yaml:"container"`
+
+	// PIDFile is the file the node's process writes its PID to, for nodes
+	// running as local processes.
+	PIDFile string `yaml:"pidFile"`
+
+	// Start is the command that starts a local process, used to bring it
+	// back after it's been killed. Containers are started with docker start.
+	Start []string `yaml:"start"`
+
+	// NetNS is the network namespace (see ip netns) a local process runs
+	// in, which network faults are applied to. Containers are faulted in
+	// their own network namespace.
+	NetNS string `yaml:"netns"`
+
+	// IP is the node's address, as seen by the other nodes, for
+	// partitions. It's looked up for containers if unset.
+	IP string `yaml:"ip"`
+
+	// Interface is the network interface that network degradation is
+	// applied to. Defaults to eth0.
+	Interface string `yaml:"interface"`
+}
+
+func (l Local) validate() []error {
+	if len(l.Nodes) == 0 {
+		return []error{fmt.Errorf("at least one node is required")}
+	}
+
+	var errs []error
+	seen := map[string]bool{}
+	for i, n := range l.Nodes {
+		if !nameRegex.MatchString(n.Name) {
+			errs = append(errs, fmt.Errorf("node %d: invalid name %q, must contain only lowercase alphanumeric characters and '-'", i+1, n.Name))
+		}
+		if seen[n.Name] {
+			errs = append(errs, fmt.Errorf("node %d: duplicate name %q", i+1, n.Name))
+		}
+		seen[n.Name] = true
+
+		if (n.Container == "") == (n.PIDFile == "") {
+			errs = append(errs, fmt.Errorf("node %d (%s): exactly one of container and pidFile is required", i+1, n.Name))
+		}
+		if n.Container != "" && (len(n.Start) > 0 || n.NetNS != "") {
+			errs = append(errs, fmt.Errorf("node %d (%s): start and netns are only supported for local processes", i+1, n.Name))
+		}
+	}
+
+	return errs
+}
+
+func (l *Local) setDefaults() {
+	for i := range l.Nodes {
+		if l.Nodes[i].Interface == "" {
+			l.Nodes[i].Interface = "eth0"
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+)
+
+// Nemesis replaces the in-order run of a scenario's experiments with a
+// random schedule of faults, each picked from the experiments.
+type Nemesis struct {
+	// Duration is the total length of the schedule, excluding time spent
+	// waiting for the database to be ready between faults.
+	Duration time.Duration `yaml:"duration"`
+
+	// MinDuration and MaxDuration bound the length of each fault. They
+	// default to 10s and the experiment duration respectively.
+	MinDuration time.Duration `yaml:"minDuration"`
+	MaxDuration time.Duration `yaml:"maxDuration"`
+
+	// MinPause and MaxPause bound the time between faults.
+	MinPause time.Duration `yaml:"minPause"`
+	MaxPause time.Duration `yaml:"maxPause"`
+}
+
+func (n Nemesis) validate() []error {
+	var errs []error
+
+	if n.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("duration must be greater than zero"))
+	}
+	if n.MinDuration <= 0 {
+		errs = append(errs, fmt.Errorf("minDuration must be greater than zero"))
+	}
+	if n.MinDuration > n.MaxDuration {
+		errs = append(errs, fmt.Errorf("minDuration must not be greater than maxDuration"))
+	}
+	if n.MinDuration > n.Duration {
+		errs = append(errs, fmt.Errorf("minDuration must not be greater than duration"))
+	}
+	if n.MinPause < 0 {
+		errs = append(errs, fmt.Errorf("minPause must not be negative"))
+	}
+	if n.MinPause > n.MaxPause {
+		errs = append(errs, fmt.Errorf("minPause must not be greater than maxPause"))
+	}
+
+	return errs
+}
+
+func (n *Nemesis) setDefaults(duration time.Duration) {
+	if n.MaxDuration == 0 {
+		n.MaxDuration = duration
+	}
+	if n.MinDuration == 0 {
+		n.MinDuration = min(time.Second*10, n.MaxDuration)
+	}
+}
@@ -0,0 +1,112 @@
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Bandwidth rates are expressed in the units understood by tc.
+var bandwidthRateRegex = regexp.MustCompile(`^[0-9]+(bps|kbps|mbps|gbps|tbps)$`)
+
+// Delay adds latency to network traffic.
+type Delay struct {
+	Latency     time.Duration `yaml:"latency"`
+	Jitter      time.Duration `yaml:"jitter"`
+	Correlation float64       `yaml:"correlation"`
+}
+
+// Rate describes the percentage of packets affected by packet loss,
+// duplication or corruption.
+type Rate struct {
+	Percent     float64 `yaml:"percent"`
+	Correlation float64 `yaml:"correlation"`
+}
+
+// Bandwidth limits the rate of network traffic.
+type Bandwidth struct {
+	Rate   string `yaml:"rate"`
+	Limit  uint32 `yaml:"limit"`
+	Buffer uint32 `yaml:"buffer"`
+}
+
+// IsNetworkDegradation returns true if the experiment slows or damages
+// network traffic rather than severing it.
+func (e Experiment) IsNetworkDegradation() bool {
+	switch e.Type {
+	case TypeNetworkDelay, TypeNetworkLoss, TypeNetworkDuplicate, TypeNetworkCorrupt, TypeNetworkBandwidth:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e Experiment) validateNetwork() []error {
+	var errs []error
+
+	switch e.Type {
+	case TypeNetworkDelay:
+		if e.Delay == nil {
+			return []error{fmt.Errorf("delay is required")}
+		}
+		if e.Delay.Latency <= 0 {
+			errs = append(errs, fmt.Errorf("delay latency must be greater than zero"))
+		}
+		if e.Delay.Jitter < 0 {
+			errs = append(errs, fmt.Errorf("delay jitter must not be negative"))
+		}
+		errs = append(errs, validatePercent("delay correlation", e.Delay.Correlation)...)
+
+	case TypeNetworkLoss:
+		errs = append(errs, validateRate("loss", e.Loss)...)
+
+	case TypeNetworkDuplicate:
+		errs = append(errs, validateRate("duplicate", e.Duplicate)...)
+
+	case TypeNetworkCorrupt:
+		errs = append(errs, validateRate("corrupt", e.Corrupt)...)
+
+	case TypeNetworkBandwidth:
+		if e.Bandwidth == nil {
+			return []error{fmt.Errorf("bandwidth is required")}
+		}
+		if !bandwidthRateRegex.MatchString(e.Bandwidth.Rate) {
+			errs = append(errs, fmt.Errorf("invalid bandwidth rate %q, expected a number followed by bps, kbps, mbps, gbps or tbps", e.Bandwidth.Rate))
+		}
+	}
+
+	return errs
+}
+
+func validateRate(name string, r *Rate) []error {
+	if r == nil {
+		return []error{fmt.Errorf("%s is required", name)}
+	}
+
+	var errs []error
+	if r.Percent <= 0 {
+		errs = append(errs, fmt.Errorf("%s percent must be greater than zero", name))
+	}
+	errs = append(errs, validatePercent(name+" percent", r.Percent)...)
+	errs = append(errs, validatePercent(name+" correlation", r.Correlation)...)
+
+	return errs
+}
+
+func validatePercent(name string, v float64) []error {
+	if v < 0 || v > 100 {
+		return []error{fmt.Errorf("%s must be between 0 and 100", name)}
+	}
+	return nil
+}
+
+func (b *Bandwidth) setDefaults() {
+	// Chaos Mesh requires both a limit and a buffer, so default them to the
+	// values used in its documentation.
+	if b.Limit == 0 {
+		b.Limit = 20971520
+	}
+	if b.Buffer == 0 {
+		b.Buffer = 10000
+	}
+}
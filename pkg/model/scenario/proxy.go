@@ -0,0 +1,90 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+)
+
+// Proxy describes the proxies that sit between the workload and each
+// database node, for running scenarios against databases outside of
+// Kubernetes. The workload must connect to the nodes' listen addresses.
+type Proxy struct {
+	// URL is the address of the Toxiproxy API, for the toxiproxy backend.
+	// Defaults to http://localhost:8474.
+	URL string `yaml:"url"`
+
+	Nodes []ProxyNode `yaml:"nodes"`
+}
+
+// ProxyNode is a database node and the address it's proxied on. Node names
+// are used in place of pod names for targets and peers.
+type ProxyNode struct {
+	Name     string `yaml:"name"`
+	Listen   string `yaml:"listen"`
+	Upstream string `yaml:"upstream"`
+}
+
+// Timeout describes how long connections are stalled, reset or closed
+// after.
+type Timeout struct {
+	// After is how long data is stalled for before the connection is
+	// closed (or reset), or how long closes are delayed for slow closes.
+	// Stalled connections are held open indefinitely if zero.
+	After time.Duration `yaml:"after"`
+}
+
+// IsConnectionFault returns true if the experiment interrupts connections
+// to the database nodes, which is only supported by proxy backends.
+func (e Experiment) IsConnectionFault() bool {
+	switch e.Type {
+	case TypeNetworkTimeout, TypeNetworkReset, TypeNetworkDrop, TypeNetworkHalfOpen, TypeNetworkSlowClose:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e Experiment) validateConnection() []error {
+	if e.Type == TypeNetworkSlowClose && (e.Timeout == nil || e.Timeout.After <= 0) {
+		return []error{fmt.Errorf("timeout after must be greater than zero for slow closes")}
+	}
+
+	if e.Timeout != nil && e.Timeout.After < 0 {
+		return []error{fmt.Errorf("timeout after must not be negative")}
+	}
+
+	return nil
+}
+
+func (p Proxy) validate() []error {
+	if len(p.Nodes) == 0 {
+		return []error{fmt.Errorf("at least one node is required")}
+	}
+
+	var errs []error
+	seen := map[string]bool{}
+	for i, n := range p.Nodes {
+		if !nameRegex.MatchString(n.Name) {
+			errs = append(errs, fmt.Errorf("node %d: invalid name %q, must contain only lowercase alphanumeric characters and '-'", i+1, n.Name))
+		}
+		if seen[n.Name] {
+			errs = append(errs, fmt.Errorf("node %d: duplicate name %q", i+1, n.Name))
+		}
+		seen[n.Name] = true
+
+		if n.Listen == "" {
+			errs = append(errs, fmt.Errorf("node %d (%s): listen is required", i+1, n.Name))
+		}
+		if n.Upstream == "" {
+			errs = append(errs, fmt.Errorf("node %d (%s): upstream is required", i+1, n.Name))
+		}
+	}
+
+	return errs
+}
+
+func (p *Proxy) setDefaults() {
+	if p.URL == "" {
+		p.URL = "http://localhost:8474"
+	}
+}
@@ -0,0 +1,459 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Experiment types supported by the chaos runner.
+const (
+	TypePodFailure       = "pod-failure"
+	TypePodKill          = "pod-kill"
+	TypePartition        = "partition"
+	TypeNetworkDelay     = "network-delay"
+	TypeNetworkLoss      = "network-loss"
+	TypeNetworkDuplicate = "network-duplicate"
+	TypeNetworkCorrupt   = "network-corrupt"
+	TypeNetworkBandwidth = "network-bandwidth"
+	TypeDiskLatency      = "disk-latency"
+	TypeDiskFault        = "disk-fault"
+	TypeDiskAttrOverride = "disk-attr-override"
+	TypeDiskMistake      = "disk-mistake"
+	TypeClockSkew        = "clock-skew"
+	TypeStress           = "stress"
+	TypeDNSError         = "dns-error"
+	TypeDNSRandom        = "dns-random"
+	TypePodEvict         = "pod-evict"
+	TypeNodeDrain        = "node-drain"
+	TypeScale            = "scale"
+	TypeNetworkTimeout   = "network-timeout"
+	TypeNetworkReset     = "network-reset"
+	TypeNetworkDrop      = "network-drop"
+	TypeNetworkHalfOpen  = "network-half-open"
+	TypeNetworkSlowClose = "network-slow-close"
+	TypePodFreeze        = "pod-freeze"
+	TypeNodeCordon       = "node-cordon"
+	TypeKubeletStop      = "kubelet-stop"
+
+	// TypeConcurrent runs a group of experiments at the same time.
+	TypeConcurrent = "concurrent"
+)
+
+// Partition topologies.
+const (
+	// TopologyPairs partitions each ordered pair of pods in turn.
+	TopologyPairs = "pairs"
+
+	// TopologyIsolate partitions each pod from all of the others in turn.
+	TopologyIsolate = "isolate"
+
+	// TopologyMajorityMinority splits the pods into a majority and a
+	// minority, rotating which pods are in the minority.
+	TopologyMajorityMinority = "majority-minority"
+
+	// TopologyBridge splits the pods into two halves that can only
+	// communicate through a single bridge pod, rotating the bridge.
+	TopologyBridge = "bridge"
+
+	// TopologyRandomHalves splits the pods into two random halves.
+	TopologyRandomHalves = "random-halves"
+)
+
+// Well-known failure domains, which correspond to node labels.
+const (
+	DomainZone   = "zone"
+	DomainRegion = "region"
+	DomainNode   = "node"
+)
+
+// Experiment modes.
+const (
+	// ModeOne runs the experiment against each target in turn.
+	ModeOne = "one"
+
+	// ModeAll runs the experiment against all targets at once.
+	ModeAll = "all"
+)
+
+var (
+	types = []string{
+		TypePodFailure, TypePodKill, TypePartition,
+		TypeNetworkDelay, TypeNetworkLoss, TypeNetworkDuplicate, TypeNetworkCorrupt, TypeNetworkBandwidth,
+		TypeDiskLatency, TypeDiskFault, TypeDiskAttrOverride, TypeDiskMistake,
+		TypeClockSkew, TypeStress, TypeDNSError, TypeDNSRandom,
+		TypePodEvict, TypeNodeDrain, TypeScale, TypePodFreeze, TypeNodeCordon, TypeKubeletStop,
+		TypeNetworkTimeout, TypeNetworkReset, TypeNetworkDrop, TypeNetworkHalfOpen, TypeNetworkSlowClose,
+		TypeConcurrent,
+	}
+	modes      = []string{ModeOne, ModeAll}
+	directions = []string{"to", "from", "both"}
+	topologies = []string{TopologyPairs, TopologyIsolate, TopologyMajorityMinority, TopologyBridge, TopologyRandomHalves}
+
+	// Experiment names are used in Chaos Mesh object names, so must be valid
+	// Kubernetes resource names.
+	nameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// Scenario describes the chaos experiments to run and the order in which to
+// run them.
+type Scenario struct {
+	Experiments []Experiment `yaml:"experiments"`
+
+	// Nemesis, if set, runs a random schedule of faults picked from the
+	// experiments instead of running each of them in order.
+	Nemesis *Nemesis `yaml:"nemesis"`
+
+	// Proxy configures the proxied database nodes for proxy backends.
+	Proxy *Proxy `yaml:"proxy"`
+
+	// Local configures the database nodes for the local backend.
+	Local *Local `yaml:"local"`
+}
+
+// Experiment describes a single chaos experiment.
+type Experiment struct {
+	Name      string        `yaml:"name"`
+	Type      string        `yaml:"type"`
+	Duration  time.Duration `yaml:"duration"`
+	Mode      string        `yaml:"mode"`
+	Targets   []string      `yaml:"targets"`
+	Direction string        `yaml:"direction"`
+	Topology  string        `yaml:"topology"`
+	Domain    string        `yaml:"domain"`
+	Repeat    int           `yaml:"repeat"`
+	Pause     time.Duration `yaml:"pause"`
+
+	// Peers limits network degradation to traffic between the targets and
+	// these pods.
+	Peers []string `yaml:"peers"`
+
+	Delay     *Delay     `yaml:"delay"`
+	Loss      *Rate      `yaml:"loss"`
+	Duplicate *Rate      `yaml:"duplicate"`
+	Corrupt   *Rate      `yaml:"corrupt"`
+	Bandwidth *Bandwidth `yaml:"bandwidth"`
+
+	Disk   *Disk   `yaml:"disk"`
+	Clock  *Clock  `yaml:"clock"`
+	Stress *Stress `yaml:"stress"`
+	DNS    *DNS    `yaml:"dns"`
+	Scale  *Scale  `yaml:"scale"`
+	Drain  *Drain  `yaml:"drain"`
+
+	Timeout *Timeout `yaml:"timeout"`
+
+	// Experiments are run at the same time by concurrent experiments, each
+	// starting Start after the group.
+	Experiments []Experiment  `yaml:"experiments"`
+	Start       time.Duration `yaml:"start"`
+}
+
+// Default returns the scenario that runs when no scenario file is provided.
+func Default(duration time.Duration) Scenario {
+	s := Scenario{
+		Experiments: []Experiment{
+			{Type: TypePodFailure},
+			{Type: TypePodKill},
+			{Type: TypePartition, Direction: "both"},
+			{Type: TypePartition, Direction: "to"},
+		},
+	}
+
+	s.setDefaults(duration)
+	return s
+}
+
+// Load reads a YAML or JSON scenario file, applies defaults to any missing
+// values and validates the result.
+func Load(path string, duration time.Duration) (Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("reading scenario file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	var s Scenario
+	if err = dec.Decode(&s); err != nil {
+		return Scenario{}, fmt.Errorf("parsing scenario file: %w", err)
+	}
+
+	s.setDefaults(duration)
+
+	if err = s.Validate(); err != nil {
+		return Scenario{}, fmt.Errorf("validating scenario file: %w", err)
+	}
+
+	return s, nil
+}
+
+// Validate returns an error describing every problem found in the scenario.
+func (s Scenario) Validate() error {
+	if len(s.Experiments) == 0 {
+		return fmt.Errorf("no experiments defined")
+	}
+
+	var errs []error
+	for i, exp := range s.Experiments {
+		for _, err := range exp.validate() {
+			errs = append(errs, fmt.Errorf("experiment %d (%s): %w", i+1, exp.Name, err))
+		}
+
+		if s.Nemesis != nil && exp.Domain != "" {
+			errs = append(errs, fmt.Errorf("experiment %d (%s): domain is not supported in nemesis mode", i+1, exp.Name))
+		}
+		if s.Nemesis != nil && exp.Type == TypeConcurrent {
+			errs = append(errs, fmt.Errorf("experiment %d (%s): concurrent experiments are not supported in nemesis mode", i+1, exp.Name))
+		}
+		if exp.Start != 0 {
+			errs = append(errs, fmt.Errorf("experiment %d (%s): start is only supported for experiments in a concurrent group", i+1, exp.Name))
+		}
+	}
+
+	if s.Nemesis != nil {
+		for _, err := range s.Nemesis.validate() {
+			errs = append(errs, fmt.Errorf("nemesis: %w", err))
+		}
+	}
+
+	if s.Proxy != nil {
+		for _, err := range s.Proxy.validate() {
+			errs = append(errs, fmt.Errorf("proxy: %w", err))
+		}
+	}
+
+	if s.Local != nil {
+		for _, err := range s.Local.validate() {
+			errs = append(errs, fmt.Errorf("local: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e Experiment) validate() []error {
+	var errs []error
+
+	if !slices.Contains(types, e.Type) {
+		errs = append(errs, fmt.Errorf("invalid type %q, expected one of %v", e.Type, types))
+	}
+
+	if !nameRegex.MatchString(e.Name) {
+		errs = append(errs, fmt.Errorf("invalid name %q, must contain only lowercase alphanumeric characters and '-'", e.Name))
+	}
+
+	if e.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("duration must be greater than zero"))
+	}
+
+	if e.Pause < 0 {
+		errs = append(errs, fmt.Errorf("pause must not be negative"))
+	}
+
+	if e.Repeat < 1 {
+		errs = append(errs, fmt.Errorf("repeat must be at least 1"))
+	}
+
+	if !slices.Contains(modes, e.Mode) {
+		errs = append(errs, fmt.Errorf("invalid mode %q, expected one of %v", e.Mode, modes))
+	}
+
+	switch e.Type {
+	case TypePartition:
+		if !slices.Contains(directions, e.Direction) {
+			errs = append(errs, fmt.Errorf("invalid direction %q, expected one of %v", e.Direction, directions))
+		}
+		if e.Mode != ModeOne {
+			errs = append(errs, fmt.Errorf("partitions only support mode %q", ModeOne))
+		}
+		if !slices.Contains(topologies, e.Topology) {
+			errs = append(errs, fmt.Errorf("invalid topology %q, expected one of %v", e.Topology, topologies))
+		}
+
+	case TypeNetworkDelay, TypeNetworkLoss, TypeNetworkDuplicate, TypeNetworkCorrupt, TypeNetworkBandwidth:
+		if !slices.Contains(directions, e.Direction) {
+			errs = append(errs, fmt.Errorf("invalid direction %q, expected one of %v", e.Direction, directions))
+		}
+		errs = append(errs, e.validateNetwork()...)
+
+	case TypeDiskLatency, TypeDiskFault, TypeDiskAttrOverride, TypeDiskMistake:
+		errs = append(errs, e.validateDisk()...)
+
+	case TypeClockSkew:
+		errs = append(errs, e.validateClock()...)
+
+	case TypeStress:
+		errs = append(errs, e.validateStress()...)
+
+	case TypeDNSError, TypeDNSRandom:
+		errs = append(errs, e.validateDNS()...)
+
+	case TypeScale:
+		errs = append(errs, e.validateScale()...)
+
+	case TypeNetworkTimeout, TypeNetworkReset, TypeNetworkDrop, TypeNetworkHalfOpen, TypeNetworkSlowClose:
+		if !slices.Contains(directions, e.Direction) {
+			errs = append(errs, fmt.Errorf("invalid direction %q, expected one of %v", e.Direction, directions))
+		}
+		errs = append(errs, e.validateConnection()...)
+
+	case TypeConcurrent:
+		errs = append(errs, e.validateConcurrent()...)
+	}
+
+	if len(e.Experiments) > 0 && e.Type != TypeConcurrent {
+		errs = append(errs, fmt.Errorf("experiments are only supported for concurrent experiments"))
+	}
+
+	if e.Direction != "" && e.Type != TypePartition && !e.IsNetworkDegradation() && !e.IsConnectionFault() {
+		errs = append(errs, fmt.Errorf("direction is only supported for network experiments"))
+	}
+
+	if e.Domain != "" && e.Type == TypeClockSkew {
+		errs = append(errs, fmt.Errorf("domain is not supported for clock skew experiments"))
+	}
+
+	if e.Domain != "" && e.Type == TypeScale {
+		errs = append(errs, fmt.Errorf("domain is not supported for scale experiments"))
+	}
+
+	if len(e.Targets) > 0 && e.Type == TypeScale {
+		errs = append(errs, fmt.Errorf("targets are not supported for scale experiments, which always remove the highest ordinals"))
+	}
+
+	if e.Domain != "" && len(e.Peers) > 0 {
+		errs = append(errs, fmt.Errorf("peers are not supported with a domain, as each domain's peers are the other domains"))
+	}
+
+	if e.Topology != "" && e.Type != TypePartition {
+		errs = append(errs, fmt.Errorf("topology is only supported for partitions"))
+	}
+
+	if e.Disk != nil && !e.IsDisk() {
+		errs = append(errs, fmt.Errorf("disk is only supported for disk experiments"))
+	}
+
+	if e.Drain != nil && e.Type != TypeNodeDrain {
+		errs = append(errs, fmt.Errorf("drain is only supported for node drain experiments"))
+	}
+
+	if e.Delay != nil && e.Type != TypeNetworkDelay {
+		errs = append(errs, fmt.Errorf("delay is only supported for network delay experiments"))
+	}
+
+	if e.Loss != nil && e.Type != TypeNetworkLoss {
+		errs = append(errs, fmt.Errorf("loss is only supported for network loss experiments"))
+	}
+
+	if e.Duplicate != nil && e.Type != TypeNetworkDuplicate {
+		errs = append(errs, fmt.Errorf("duplicate is only supported for network duplicate experiments"))
+	}
+
+	if e.Corrupt != nil && e.Type != TypeNetworkCorrupt {
+		errs = append(errs, fmt.Errorf("corrupt is only supported for network corrupt experiments"))
+	}
+
+	if e.Bandwidth != nil && e.Type != TypeNetworkBandwidth {
+		errs = append(errs, fmt.Errorf("bandwidth is only supported for network bandwidth experiments"))
+	}
+
+	if e.Clock != nil && e.Type != TypeClockSkew {
+		errs = append(errs, fmt.Errorf("clock is only supported for clock skew experiments"))
+	}
+
+	if e.Stress != nil && e.Type != TypeStress {
+		errs = append(errs, fmt.Errorf("stress is only supported for stress experiments"))
+	}
+
+	if e.DNS != nil && e.Type != TypeDNSError && e.Type != TypeDNSRandom {
+		errs = append(errs, fmt.Errorf("dns is only supported for dns experiments"))
+	}
+
+	if e.Timeout != nil && e.Type != TypeNetworkTimeout && e.Type != TypeNetworkReset && e.Type != TypeNetworkSlowClose {
+		errs = append(errs, fmt.Errorf("timeout is only supported for network timeout, reset and slow close experiments"))
+	}
+
+	if e.Scale != nil && e.Type != TypeScale {
+		errs = append(errs, fmt.Errorf("scale is only supported for scale experiments"))
+	}
+
+	if len(e.Peers) > 0 && !e.IsNetworkDegradation() {
+		errs = append(errs, fmt.Errorf("peers are only supported for network degradation experiments"))
+	}
+
+	return errs
+}
+
+func (s *Scenario) setDefaults(duration time.Duration) {
+	if s.Nemesis != nil {
+		s.Nemesis.setDefaults(duration)
+	}
+	if s.Proxy != nil {
+		s.Proxy.setDefaults()
+	}
+	if s.Local != nil {
+		s.Local.setDefaults()
+	}
+
+	for i := range s.Experiments {
+		s.Experiments[i].setDefaults(duration)
+	}
+}
+
+func (e *Experiment) setDefaults(duration time.Duration) {
+	if e.Duration == 0 {
+		e.Duration = duration
+	}
+	if e.Repeat == 0 {
+		e.Repeat = 1
+	}
+	if e.Mode == "" {
+		e.Mode = ModeOne
+	}
+	if (e.Type == TypePartition || e.IsConnectionFault()) && e.Direction == "" {
+		e.Direction = "both"
+	}
+	if e.Type == TypePartition && e.Topology == "" {
+		e.Topology = TopologyPairs
+	}
+	if e.IsNetworkDegradation() && e.Direction == "" {
+		e.Direction = "to"
+	}
+	if e.Bandwidth != nil {
+		e.Bandwidth.setDefaults()
+	}
+	if e.Disk != nil {
+		e.Disk.setDefaults()
+	}
+	if e.Clock != nil {
+		e.Clock.setDefaults()
+	}
+	for i := range e.Experiments {
+		e.Experiments[i].setDefaults(duration)
+	}
+	if e.Name == "" {
+		e.Name = defaultName(*e)
+	}
+}
+
+func defaultName(e Experiment) string {
+	switch e.Type {
+	case TypePartition:
+		if e.Topology != TopologyPairs {
+			return fmt.Sprintf("network-chaos-%s-%s", e.Topology, e.Direction)
+		}
+		return fmt.Sprintf("network-chaos-%s", e.Direction)
+	case TypePodFailure, TypePodKill:
+		return fmt.Sprintf("pod-chaos-%s", e.Type)
+	default:
+		return e.Type
+	}
+}
@@ -0,0 +1,261 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name   string
+		yaml   string
+		exp    []Experiment
+		expErr string
+	}{
+		{
+			name: "defaults applied",
+			yaml: `
+experiments:
+  - type: pod-kill
+  - type: partition
+  - type: network-delay
+    delay:
+      latency: 100ms
+`,
+			exp: []Experiment{
+				{Name: "pod-chaos-pod-kill", Type: TypePodKill, Duration: time.Minute, Mode: ModeOne, Repeat: 1},
+				{Name: "network-chaos-both", Type: TypePartition, Duration: time.Minute, Mode: ModeOne, Repeat: 1, Direction: "both", Topology: TopologyPairs},
+				{Name: "network-delay", Type: TypeNetworkDelay, Duration: time.Minute, Mode: ModeOne, Repeat: 1, Direction: "to", Delay: &Delay{Latency: 100 * time.Millisecond}},
+			},
+		},
+		{
+			name: "explicit values kept",
+			yaml: `
+experiments:
+  - name: kill-first-node
+    type: pod-kill
+    mode: all
+    duration: 10s
+    targets: [db-0]
+    repeat: 3
+    pause: 30s
+`,
+			exp: []Experiment{
+				{Name: "kill-first-node", Type: TypePodKill, Duration: 10 * time.Second, Mode: ModeAll, Targets: []string{"db-0"}, Repeat: 3, Pause: 30 * time.Second},
+			},
+		},
+		{
+			name: "json",
+			yaml: `{"experiments": [{"type": "pod-failure", "duration": "5s"}]}`,
+			exp: []Experiment{
+				{Name: "pod-chaos-pod-failure", Type: TypePodFailure, Duration: 5 * time.Second, Mode: ModeOne, Repeat: 1},
+			},
+		},
+		{
+			name:   "unknown field",
+			yaml:   "experiments:\n  - type: pod-kill\n    duratoin: 10s\n",
+			expErr: "field duratoin not found",
+		},
+		{
+			name:   "no experiments",
+			yaml:   "experiments: []\n",
+			expErr: "no experiments defined",
+		},
+		{
+			name:   "invalid type",
+			yaml:   "experiments:\n  - type: meteor-strike\n",
+			expErr: `invalid type "meteor-strike"`,
+		},
+		{
+			name:   "invalid name",
+			yaml:   "experiments:\n  - name: Kill_Node\n    type: pod-kill\n",
+			expErr: `invalid name "Kill_Node"`,
+		},
+		{
+			name:   "invalid mode",
+			yaml:   "experiments:\n  - type: pod-kill\n    mode: some\n",
+			expErr: `invalid mode "some"`,
+		},
+		{
+			name:   "negative pause",
+			yaml:   "experiments:\n  - type: pod-kill\n    pause: -1s\n",
+			expErr: "pause must not be negative",
+		},
+		{
+			name:   "partition in mode all",
+			yaml:   "experiments:\n  - type: partition\n    mode: all\n",
+			expErr: `partitions only support mode "one"`,
+		},
+		{
+			name:   "invalid direction",
+			yaml:   "experiments:\n  - type: partition\n    direction: sideways\n",
+			expErr: `invalid direction "sideways"`,
+		},
+		{
+			name:   "direction on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    direction: to\n",
+			expErr: "direction is only supported for network experiments",
+		},
+		{
+			name:   "topology on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    topology: isolate\n",
+			expErr: "topology is only supported for partitions",
+		},
+		{
+			name:   "peers on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    peers: [db-1]\n",
+			expErr: "peers are only supported for network degradation experiments",
+		},
+		{
+			name:   "disk on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    disk:\n      volumePath: /data\n",
+			expErr: "disk is only supported for disk experiments",
+		},
+		{
+			name:   "disk experiment without disk",
+			yaml:   "experiments:\n  - type: disk-latency\n",
+			expErr: "disk is required",
+		},
+		{
+			name:   "delay on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    delay:\n      latency: 100ms\n",
+			expErr: "delay is only supported for network delay experiments",
+		},
+		{
+			name:   "loss on network delay experiment",
+			yaml:   "experiments:\n  - type: network-delay\n    delay:\n      latency: 100ms\n    loss:\n      percent: 10\n",
+			expErr: "loss is only supported for network loss experiments",
+		},
+		{
+			name:   "duplicate on partition",
+			yaml:   "experiments:\n  - type: partition\n    duplicate:\n      percent: 10\n",
+			expErr: "duplicate is only supported for network duplicate experiments",
+		},
+		{
+			name:   "corrupt on pod experiment",
+			yaml:   "experiments:\n  - type: pod-failure\n    corrupt:\n      percent: 10\n",
+			expErr: "corrupt is only supported for network corrupt experiments",
+		},
+		{
+			name:   "bandwidth on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    bandwidth:\n      rate: 1mbps\n",
+			expErr: "bandwidth is only supported for network bandwidth experiments",
+		},
+		{
+			name:   "clock on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    clock:\n      offsets: [500ms]\n",
+			expErr: "clock is only supported for clock skew experiments",
+		},
+		{
+			name:   "stress on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    stress:\n      cpu:\n        workers: 1\n",
+			expErr: "stress is only supported for stress experiments",
+		},
+		{
+			name:   "dns on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    dns:\n      patterns: [cockroachdb-*]\n",
+			expErr: "dns is only supported for dns experiments",
+		},
+		{
+			name:   "timeout on network drop experiment",
+			yaml:   "experiments:\n  - type: network-drop\n    timeout:\n      after: 1s\n",
+			expErr: "timeout is only supported for network timeout, reset and slow close experiments",
+		},
+		{
+			name:   "scale on pod experiment",
+			yaml:   "experiments:\n  - type: pod-kill\n    scale:\n      replicas: 2\n",
+			expErr: "scale is only supported for scale experiments",
+		},
+		{
+			name:   "concurrent with one experiment",
+			yaml:   "experiments:\n  - type: concurrent\n    experiments:\n      - type: pod-kill\n",
+			expErr: "at least 2 experiments are required",
+		},
+		{
+			name:   "start outside concurrent group",
+			yaml:   "experiments:\n  - type: pod-kill\n    start: 5s\n",
+			expErr: "start is only supported for experiments in a concurrent group",
+		},
+		{
+			name: "errors reported with experiment",
+			yaml: `
+experiments:
+  - type: pod-kill
+  - name: bad
+    type: pod-kill
+    repeat: -1
+`,
+			expErr: "experiment 2 (bad): repeat must be at least 1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if err := os.WriteFile(path, []byte(c.yaml), 0o644); err != nil {
+				t.Fatalf("writing scenario: %v", err)
+			}
+
+			s, err := Load(path, time.Minute)
+			if c.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expErr) {
+					t.Fatalf("expected error containing %q, got %v", c.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(s.Experiments, c.exp) {
+				t.Fatalf("expected %+v, got %+v", c.exp, s.Experiments)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "reading scenario file") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestLoadExamples(t *testing.T) {
+	paths, err := filepath.Glob("../../../examples/scenarios/*.yaml")
+	if err != nil {
+		t.Fatalf("finding examples: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no example scenarios found")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if _, err := Load(path, time.Minute); err != nil {
+				t.Fatalf("loading example: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	s := Default(time.Minute)
+	if err := s.Validate(); err != nil {
+		t.Fatalf("default scenario is invalid: %v", err)
+	}
+
+	var names []string
+	for _, exp := range s.Experiments {
+		names = append(names, exp.Name)
+	}
+
+	exp := []string{"pod-chaos-pod-failure", "pod-chaos-pod-kill", "network-chaos-both", "network-chaos-to"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("expected experiments %v, got %v", exp, names)
+	}
+}
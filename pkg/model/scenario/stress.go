@@ -0,0 +1,60 @@
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Memory sizes are either absolute (e.g. 256MB) or a percentage of the
+// pod's memory (e.g. 50%).
+var memorySizeRegex = regexp.MustCompile(`^[0-9]+(%|B|KB|MB|GB|TB)$`)
+
+// Stress describes the CPU and memory pressure to apply to target pods.
+type Stress struct {
+	CPU    *CPUStress    `yaml:"cpu"`
+	Memory *MemoryStress `yaml:"memory"`
+}
+
+type CPUStress struct {
+	// Workers is the number of threads generating CPU load.
+	Workers int `yaml:"workers"`
+
+	// Load is the percentage of a CPU each worker occupies.
+	Load int `yaml:"load"`
+}
+
+type MemoryStress struct {
+	// Workers is the number of threads allocating memory.
+	Workers int `yaml:"workers"`
+
+	// Size is the amount of memory each worker allocates.
+	Size string `yaml:"size"`
+}
+
+func (e Experiment) validateStress() []error {
+	if e.Stress == nil || (e.Stress.CPU == nil && e.Stress.Memory == nil) {
+		return []error{fmt.Errorf("stress requires cpu and/or memory")}
+	}
+
+	var errs []error
+
+	if cpu := e.Stress.CPU; cpu != nil {
+		if cpu.Workers <= 0 {
+			errs = append(errs, fmt.Errorf("stress cpu workers must be greater than zero"))
+		}
+		if cpu.Load <= 0 || cpu.Load > 100 {
+			errs = append(errs, fmt.Errorf("stress cpu load must be between 1 and 100"))
+		}
+	}
+
+	if mem := e.Stress.Memory; mem != nil {
+		if mem.Workers <= 0 {
+			errs = append(errs, fmt.Errorf("stress memory workers must be greater than zero"))
+		}
+		if !memorySizeRegex.MatchString(mem.Size) {
+			errs = append(errs, fmt.Errorf("invalid stress memory size %q, expected a size (e.g. 256MB) or percentage (e.g. 50%%)", mem.Size))
+		}
+	}
+
+	return errs
+}
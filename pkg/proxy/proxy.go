@@ -0,0 +1,378 @@
+// Package proxy implements a TCP proxy that can inject faults into the
+// traffic between clients and a database node, for running network chaos
+// without a cluster.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// Direction is the direction of traffic through the proxy.
+type Direction string
+
+const (
+	// Upstream traffic flows from clients to the database.
+	Upstream Direction = "upstream"
+
+	// Downstream traffic flows from the database back to clients.
+	Downstream Direction = "downstream"
+)
+
+// Faults describes how traffic in one direction is disrupted.
+type Faults struct {
+	// Delay and Jitter delay each chunk of data by Delay ± Jitter.
+	Delay  time.Duration
+	Jitter time.Duration
+
+	// Drop silently discards data, while the connection stays open.
+	Drop bool
+
+	// Blackhole holds data until the fault is removed, as a partition
+	// would, while the connection stays open.
+	Blackhole bool
+
+	// HalfOpen closes the connection to the receiver without telling the
+	// sender, whose data is discarded. The sender's connection is closed
+	// once the fault is removed.
+	HalfOpen bool
+
+	// SlowClose delays passing on the sender closing the connection.
+	SlowClose time.Duration
+}
+
+// merge combines two sets of faults affecting the same direction.
+func (f Faults) merge(o Faults) Faults {
+	return Faults{
+		Delay:     f.Delay + o.Delay,
+		Jitter:    f.Jitter + o.Jitter,
+		Drop:      f.Drop || o.Drop,
+		Blackhole: f.Blackhole || o.Blackhole,
+		HalfOpen:  f.HalfOpen || o.HalfOpen,
+		SlowClose: max(f.SlowClose, o.SlowClose),
+	}
+}
+
+func (f Faults) delay() time.Duration {
+	if f.Jitter <= 0 {
+		return f.Delay
+	}
+
+	jitter := time.Duration(rand.Int64N(int64(f.Jitter)*2+1)) - f.Jitter
+	return max(f.Delay+jitter, 0)
+}
+
+type faultKey struct {
+	name string
+	dir  Direction
+}
+
+// Proxy forwards connections from a local address to an upstream address,
+// applying any faults that have been added. Faults take effect immediately,
+// including on open connections.
+type Proxy struct {
+	upstream string
+	listener net.Listener
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	faults map[faultKey]Faults
+	links  map[*link]struct{}
+
+	// changed is closed and replaced whenever faults are added or removed,
+	// to wake connections waiting on a blackhole.
+	changed chan struct{}
+}
+
+// Listen starts a proxy listening on addr (e.g. 127.0.0.1:26001, or port 0
+// for a random port) and forwarding to upstream.
+func Listen(addr, upstream string) (*Proxy, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	p := &Proxy{
+		upstream: upstream,
+		listener: l,
+		done:     make(chan struct{}),
+		faults:   map[faultKey]Faults{},
+		links:    map[*link]struct{}{},
+		changed:  make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.serve()
+
+	return p, nil
+}
+
+// Addr returns the address the proxy is listening on.
+func (p *Proxy) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// Add adds a named set of faults to a direction, replacing any previously
+// added under the same name. Faults added under different names combine.
+func (p *Proxy) Add(name string, dir Direction, f Faults) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.faults[faultKey{name: name, dir: dir}] = f
+	p.notify()
+}
+
+// Remove removes the named faults from both directions.
+func (p *Proxy) Remove(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.faults, faultKey{name: name, dir: Upstream})
+	delete(p.faults, faultKey{name: name, dir: Downstream})
+	p.notify()
+}
+
+// Clear removes every fault.
+func (p *Proxy) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clear(p.faults)
+	p.notify()
+}
+
+// Close stops the proxy and closes every open connection.
+func (p *Proxy) Close() error {
+	close(p.done)
+	err := p.listener.Close()
+
+	p.mu.Lock()
+	for l := range p.links {
+		l.close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return err
+}
+
+// notify wakes connections waiting for the faults to change and closes
+// half-open links whose fault has been removed. p.mu must be held.
+func (p *Proxy) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+
+	halfOpen := map[Direction]bool{
+		Upstream:   p.merged(Upstream).HalfOpen,
+		Downstream: p.merged(Downstream).HalfOpen,
+	}
+	for l := range p.links {
+		l.halfOpen.Range(func(_, dir any) bool {
+			if halfOpen[dir.(Direction)] {
+				return true
+			}
+			l.close()
+			return false
+		})
+	}
+}
+
+// current returns the combined faults affecting a direction, and a channel
+// that's closed when they change.
+func (p *Proxy) current(dir Direction) (Faults, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.merged(dir), p.changed
+}
+
+// merged returns the combined faults affecting a direction. p.mu must be
+// held.
+func (p *Proxy) merged(dir Direction) Faults {
+	var f Faults
+	for key, faults := range p.faults {
+		if key.dir == dir {
+			f = f.merge(faults)
+		}
+	}
+	return f
+}
+
+func (p *Proxy) serve() {
+	defer p.wg.Done()
+
+	var backoff time.Duration
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			// Back off on errors such as running out of file descriptors,
+			// rather than spinning until they clear.
+			backoff = min(max(backoff*2, time.Millisecond*5), time.Second)
+			select {
+			case <-p.done:
+				return
+			case <-time.After(backoff):
+				continue
+			}
+		}
+		backoff = 0
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.handle(client)
+		}()
+	}
+}
+
+// link is a client connection and the upstream connection it's forwarded
+// to.
+type link struct {
+	client net.Conn
+	server net.Conn
+	once   sync.Once
+
+	// halfOpen holds the direction of each connection closed to make the
+	// link half-open.
+	halfOpen sync.Map
+}
+
+func (l *link) close() {
+	l.once.Do(func() {
+		l.client.Close()
+		l.server.Close()
+	})
+}
+
+func (p *Proxy) handle(client net.Conn) {
+	server, err := net.DialTimeout("tcp", p.upstream, time.Second*5)
+	if err != nil {
+		client.Close()
+		return
+	}
+
+	l := &link{client: client, server: server}
+
+	// The proxy may have been closed while dialling, after it closed every
+	// link it knew about.
+	p.mu.Lock()
+	select {
+	case <-p.done:
+		p.mu.Unlock()
+		l.close()
+		return
+	default:
+		p.links[l] = struct{}{}
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.pipe(Upstream, client, server, l)
+	}()
+	go func() {
+		defer wg.Done()
+		p.pipe(Downstream, server, client, l)
+	}()
+	wg.Wait()
+
+	l.close()
+
+	p.mu.Lock()
+	delete(p.links, l)
+	p.mu.Unlock()
+}
+
+// pipe copies data in one direction until either side closes, applying
+// the direction's faults to each chunk.
+func (p *Proxy) pipe(dir Direction, src, dst net.Conn, l *link) {
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if !p.forward(dir, dst, buf[:n], l) {
+				l.close()
+				return
+			}
+		}
+
+		if err != nil {
+			// A connection closed to make the link half-open mustn't be
+			// passed on to the other side.
+			if _, ok := l.halfOpen.Load(src); ok {
+				return
+			}
+
+			if errors.Is(err, io.EOF) {
+				if f, _ := p.current(dir); f.SlowClose > 0 {
+					time.Sleep(f.SlowClose)
+				}
+				closeWrite(dst)
+				return
+			}
+
+			l.close()
+			return
+		}
+	}
+}
+
+// forward writes data to dst, returning false if the link should be closed.
+func (p *Proxy) forward(dir Direction, dst net.Conn, data []byte, l *link) bool {
+	for {
+		f, changed := p.current(dir)
+
+		switch {
+		case f.HalfOpen:
+			if _, loaded := l.halfOpen.LoadOrStore(dst, dir); !loaded {
+				dst.Close()
+
+				// The fault may have been removed since it was read, before
+				// notify could see that the link is half-open.
+				if f, _ := p.current(dir); !f.HalfOpen {
+					return false
+				}
+			}
+			return true
+
+		case f.Drop:
+			return true
+
+		case f.Blackhole:
+			select {
+			case <-changed:
+				continue
+			case <-p.done:
+				return false
+			}
+		}
+
+		if d := f.delay(); d > 0 {
+			time.Sleep(d)
+		}
+
+		_, err := dst.Write(data)
+		return err == nil
+	}
+}
+
+func closeWrite(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,272 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// echoServer starts a server that echoes back whatever it's sent.
+func echoServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// dial starts a proxy in front of an echo server and connects to it.
+func dial(t *testing.T) (*Proxy, net.Conn) {
+	t.Helper()
+
+	p, err := Listen("127.0.0.1:0", echoServer(t))
+	if err != nil {
+		t.Fatalf("starting proxy: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	conn, err := net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("dialling proxy: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return p, conn
+}
+
+// roundTrip sends a message and waits up to timeout for it to be echoed.
+func roundTrip(conn net.Conn, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	msg := []byte("ping")
+
+	if _, err := conn.Write(msg); err != nil {
+		return 0, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
+func TestProxyFaults(t *testing.T) {
+	cases := []struct {
+		name       string
+		dir        Direction
+		faults     Faults
+		expMin     time.Duration
+		expTimeout bool
+	}{
+		{name: "no faults"},
+		{name: "upstream delay", dir: Upstream, faults: Faults{Delay: 100 * time.Millisecond}, expMin: 100 * time.Millisecond},
+		{name: "downstream delay", dir: Downstream, faults: Faults{Delay: 100 * time.Millisecond}, expMin: 100 * time.Millisecond},
+		{name: "delay with jitter", dir: Upstream, faults: Faults{Delay: 100 * time.Millisecond, Jitter: 50 * time.Millisecond}, expMin: 50 * time.Millisecond},
+		{name: "upstream drop", dir: Upstream, faults: Faults{Drop: true}, expTimeout: true},
+		{name: "downstream drop", dir: Downstream, faults: Faults{Drop: true}, expTimeout: true},
+		{name: "blackhole", dir: Upstream, faults: Faults{Blackhole: true}, expTimeout: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, conn := dial(t)
+			if c.dir != "" {
+				p.Add("fault", c.dir, c.faults)
+			}
+
+			took, err := roundTrip(conn, 500*time.Millisecond)
+			if c.expTimeout {
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					t.Fatalf("expected timeout, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if took < c.expMin {
+				t.Fatalf("expected round trip of at least %s, took %s", c.expMin, took)
+			}
+		})
+	}
+}
+
+func TestProxyRemove(t *testing.T) {
+	p, conn := dial(t)
+
+	p.Add("partition", Upstream, Faults{Blackhole: true})
+	p.Add("slow", Downstream, Faults{Delay: time.Second})
+
+	// Data held by a blackhole is delivered once the fault is removed.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		p.Remove("partition")
+		p.Remove("slow")
+	}()
+
+	if _, err := roundTrip(conn, 2*time.Second); err != nil {
+		t.Fatalf("expected held data to be delivered, got %v", err)
+	}
+
+	took, err := roundTrip(conn, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if took >= time.Second {
+		t.Fatalf("expected faults to be removed, round trip took %s", took)
+	}
+}
+
+func TestProxyFaultsCombine(t *testing.T) {
+	p, conn := dial(t)
+
+	p.Add("a", Upstream, Faults{Delay: 100 * time.Millisecond})
+	p.Add("b", Upstream, Faults{Delay: 100 * time.Millisecond})
+
+	took, err := roundTrip(conn, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if took < 200*time.Millisecond {
+		t.Fatalf("expected delays to combine, round trip took %s", took)
+	}
+
+	p.Clear()
+
+	if took, err = roundTrip(conn, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if took >= 100*time.Millisecond {
+		t.Fatalf("expected faults to be cleared, round trip took %s", took)
+	}
+}
+
+func TestProxyHalfOpen(t *testing.T) {
+	p, conn := dial(t)
+
+	// The client is disconnected when data is sent back to it, while the
+	// server isn't told.
+	p.Add("half-open", Downstream, Faults{HalfOpen: true})
+
+	_, err := roundTrip(conn, time.Second)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the client connection to be closed, got %v", err)
+	}
+}
+
+func TestProxyHalfOpenRemove(t *testing.T) {
+	// An upstream server that echoes data and reports when it sees the
+	// connection close.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	serverClosed := make(chan struct{})
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		io.Copy(conn, conn)
+		close(serverClosed)
+	}()
+
+	p, err := Listen("127.0.0.1:0", l.Addr().String())
+	if err != nil {
+		t.Fatalf("starting proxy: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	conn, err := net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("dialling proxy: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	p.Add("half-open", Downstream, Faults{HalfOpen: true})
+
+	if _, err = roundTrip(conn, time.Second); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the client connection to be closed, got %v", err)
+	}
+
+	select {
+	case <-serverClosed:
+		t.Fatal("expected the server not to be told while the fault is active")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	p.Remove("half-open")
+
+	select {
+	case <-serverClosed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the server connection to be closed once the fault was removed")
+	}
+}
+
+func TestProxyClose(t *testing.T) {
+	p, err := Listen("127.0.0.1:0", echoServer(t))
+	if err != nil {
+		t.Fatalf("starting proxy: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("dialling proxy: %v", err)
+	}
+	defer conn.Close()
+
+	p.Add("partition", Upstream, Faults{Blackhole: true})
+
+	// A connection blocked on a blackhole mustn't stop the proxy closing.
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("writing: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("proxy didn't close")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the client connection to be closed, got %v", err)
+	}
+}
@@ -13,12 +13,12 @@ type OracleRepo struct {
 	db *sql.DB
 }
 
-func NewOracleRepo(url string) (*OracleRepo, error) {
+func NewOracleRepo(url string, maxConns int) (*OracleRepo, error) {
 	db, err := sql.Open("oracle", url)
 	if err != nil {
 		return nil, fmt.Errorf("opening databse connection: %w", err)
 	}
-	db.SetMaxOpenConns(3)
+	db.SetMaxOpenConns(maxConns)
 	db.SetConnMaxLifetime(time.Second * 20)
 
 	if err = db.PingContext(context.Background()); err != nil {
@@ -121,3 +121,30 @@ func (o *OracleRepo) PerformTransfer(from, to any, amount float64) (elapsed time
 func (o *OracleRepo) IsReady() (bool, error) {
 	return true, nil
 }
+
+func (o *OracleRepo) SumBalances() (float64, error) {
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	const stmt = `SELECT COALESCE(SUM(balance), 0) FROM account`
+
+	row := o.db.QueryRowContext(timeout, stmt)
+
+	var total float64
+	if err := row.Scan(&total); err != nil {
+		return 0, fmt.Errorf("summing balances: %w", err)
+	}
+
+	return total, nil
+}
+
+func (o *OracleRepo) PoolStats() PoolStats {
+	stat := o.db.Stats()
+
+	return PoolStats{
+		Open:  stat.OpenConnections,
+		InUse: stat.InUse,
+		Idle:  stat.Idle,
+		Waits: stat.WaitCount,
+	}
+}
@@ -14,12 +14,12 @@ type PostgresRepo struct {
 	db *pgxpool.Pool
 }
 
-func NewPostgresRepo(url string) (*PostgresRepo, error) {
+func NewPostgresRepo(url string, maxConns int) (*PostgresRepo, error) {
 	cfg, err := pgxpool.ParseConfig(url)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing connection string: %w", err)
 	}
-	cfg.MaxConns = 3
+	cfg.MaxConns = int32(maxConns)
 
 	// These two values combined are what drive the server.shutdown.connections.timeout
 	// setting in CockroachDB.
@@ -137,3 +137,30 @@ func (p *PostgresRepo) IsReady() (bool, error) {
 
 	return underreplicatedRanges == 0, nil
 }
+
+func (p *PostgresRepo) SumBalances() (float64, error) {
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	const stmt = `SELECT COALESCE(SUM(balance), 0) FROM account`
+
+	row := p.db.QueryRow(timeout, stmt)
+
+	var total float64
+	if err := row.Scan(&total); err != nil {
+		return 0, fmt.Errorf("summing balances: %w", err)
+	}
+
+	return total, nil
+}
+
+func (p *PostgresRepo) PoolStats() PoolStats {
+	stat := p.db.Stat()
+
+	return PoolStats{
+		Open:  int(stat.TotalConns()),
+		InUse: int(stat.AcquiredConns()),
+		Idle:  int(stat.IdleConns()),
+		Waits: stat.EmptyAcquireCount(),
+	}
+}
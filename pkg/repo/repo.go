@@ -8,4 +8,18 @@ type Repo interface {
 	FetchIDs(count int) ([]any, error)
 	PerformTransfer(from, to any, amount float64) (time.Duration, error)
 	IsReady() (bool, error)
+	SumBalances() (float64, error)
+	PoolStats() PoolStats
+}
+
+// PoolStats is a snapshot of a repo's connection pool.
+type PoolStats struct {
+	// Open is the number of open connections, both in use and idle.
+	Open  int
+	InUse int
+	Idle  int
+
+	// Waits is the cumulative number of times a connection was requested
+	// when none were available.
+	Waits int64
 }
@@ -0,0 +1,249 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/histogram"
+	"github.com/codingconcepts/db-chaos/pkg/runner"
+)
+
+// SchemaVersion is incremented whenever a breaking change is made to the
+// structure of the report.
+const SchemaVersion = 1
+
+// Report is the machine-readable summary of a db-chaos run. Durations are
+// reported in milliseconds and timestamps in RFC 3339 format.
+type Report struct {
+	SchemaVersion int                   `json:"schemaVersion"`
+	Run           Run                   `json:"run"`
+	Totals        Totals                `json:"totals"`
+	Timeline      []Event               `json:"timeline"`
+	Schedule      []Step                `json:"schedule,omitempty"`
+	Experiments   map[string]Experiment `json:"experiments"`
+	Invariants    map[string]Invariant  `json:"invariants"`
+}
+
+// Run describes the environment and configuration of a run.
+type Run struct {
+	RunID          string            `json:"runId"`
+	Seed           uint64            `json:"seed"`
+	Database       string            `json:"database"`
+	Host           string            `json:"host"`
+	Namespace      string            `json:"namespace"`
+	ChaosNamespace string            `json:"chaosNamespace"`
+	Started        time.Time         `json:"started"`
+	Finished       time.Time         `json:"finished"`
+	Flags          map[string]string `json:"flags"`
+
+	// Error describes why the run failed, such as an experiment failing or
+	// the run being interrupted, and is empty if it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+type Totals struct {
+	Errors     int     `json:"errors"`
+	DowntimeMS float64 `json:"downtimeMs"`
+	Behind     int     `json:"behind"`
+}
+
+// Event describes the lifetime of a single fault.
+type Event struct {
+	Experiment string     `json:"experiment"`
+	Kind       string     `json:"kind"`
+	Name       string     `json:"name"`
+	Applied    time.Time  `json:"applied"`
+	Deleted    time.Time  `json:"deleted"`
+	Injected   *time.Time `json:"injected,omitempty"`
+	Recovered  *time.Time `json:"recovered,omitempty"`
+	Restarted  []string   `json:"restarted,omitempty"`
+	Running    *time.Time `json:"running,omitempty"`
+	Ready      *time.Time `json:"ready,omitempty"`
+}
+
+// Step describes a fault in a nemesis schedule.
+type Step struct {
+	Experiment    string   `json:"experiment"`
+	Type          string   `json:"type"`
+	Targets       []string `json:"targets"`
+	Peers         []string `json:"peers,omitempty"`
+	ClockOffsetMS float64  `json:"clockOffsetMs,omitempty"`
+	DurationMS    float64  `json:"durationMs"`
+	PauseMS       float64  `json:"pauseMs"`
+}
+
+type Experiment struct {
+	Errors     int     `json:"errors"`
+	DowntimeMS float64 `json:"downtimeMs"`
+
+	// ActiveMS is the time the experiment's chaos was confirmed by Chaos
+	// Mesh to be active. The other stats cover the whole time the experiment
+	// was running, including waiting for its chaos to be injected and
+	// recovered.
+	ActiveMS float64 `json:"activeMs"`
+
+	Behind     int     `json:"behind"`
+	MaxLagMS   float64 `json:"maxLagMs"`
+	RecoveryMS float64 `json:"recoveryMs"`
+	Latency    Latency `json:"latency"`
+	Pool       Pool    `json:"pool"`
+}
+
+// Pool is a snapshot of the workload's connection pool, taken as an
+// experiment ended.
+type Pool struct {
+	Open  int   `json:"open"`
+	InUse int   `json:"inUse"`
+	Idle  int   `json:"idle"`
+	Waits int64 `json:"waits"`
+}
+
+type Latency struct {
+	Count  int64   `json:"count"`
+	P50MS  float64 `json:"p50Ms"`
+	P90MS  float64 `json:"p90Ms"`
+	P99MS  float64 `json:"p99Ms"`
+	P999MS float64 `json:"p999Ms"`
+	MaxMS  float64 `json:"maxMs"`
+}
+
+// Invariant describes the outcome of a balance check.
+type Invariant struct {
+	Ok       bool    `json:"ok"`
+	Expected float64 `json:"expected"`
+	Actual   float64 `json:"actual"`
+	Drift    float64 `json:"drift"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// New builds a report from the results of a run.
+func New(run Run, results runner.Results, timeline []runner.Event, schedule []runner.NemesisStep) Report {
+	r := Report{
+		SchemaVersion: SchemaVersion,
+		Run:           run,
+		Totals: Totals{
+			Errors:     results.TotalErrors,
+			DowntimeMS: ms(results.TotalDowntime),
+			Behind:     results.TotalBehind,
+		},
+		Timeline:    []Event{},
+		Experiments: map[string]Experiment{},
+		Invariants:  map[string]Invariant{},
+	}
+
+	for _, e := range timeline {
+		r.Timeline = append(r.Timeline, Event{
+			Experiment: e.Experiment,
+			Kind:       e.Kind,
+			Name:       e.Name,
+			Applied:    e.Applied,
+			Deleted:    e.Deleted,
+			Injected:   timestamp(e.Injected),
+			Recovered:  timestamp(e.Recovered),
+			Restarted:  e.Restarted,
+			Running:    timestamp(e.Running),
+			Ready:      timestamp(e.Ready),
+		})
+	}
+
+	for _, s := range schedule {
+		step := Step{
+			Experiment: s.Experiment.Name,
+			Type:       s.Experiment.Type,
+			Targets:    s.Targets,
+			Peers:      s.Peers,
+			DurationMS: ms(s.Experiment.Duration),
+			PauseMS:    ms(s.Pause),
+		}
+		if s.Experiment.Clock != nil {
+			step.ClockOffsetMS = ms(s.Experiment.Clock.Offsets[0])
+		}
+		r.Schedule = append(r.Schedule, step)
+	}
+
+	active := runner.ActiveDurations(timeline)
+
+	for name, stats := range results.Stats {
+		r.Experiments[name] = Experiment{
+			Errors:     stats.ErrorCount,
+			DowntimeMS: ms(stats.Downtime),
+			ActiveMS:   ms(active[name]),
+			Behind:     stats.Behind,
+			MaxLagMS:   ms(stats.MaxLag),
+			RecoveryMS: ms(stats.Recovery),
+			Latency:    latency(stats.Latency.Summary()),
+			Pool: Pool{
+				Open:  stats.Pool.Open,
+				InUse: stats.Pool.InUse,
+				Idle:  stats.Pool.Idle,
+				Waits: stats.Pool.Waits,
+			},
+		}
+	}
+
+	for name, check := range results.Invariants {
+		inv := Invariant{
+			Ok:       check.Ok(),
+			Expected: check.Expected,
+			Actual:   check.Actual,
+			Drift:    check.Drift,
+		}
+		if check.Err != nil {
+			inv.Error = check.Err.Error()
+		}
+		r.Invariants[name] = inv
+	}
+
+	return r
+}
+
+// Write writes the report to a file as indented JSON.
+func (r Report) Write(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling report: %w", err)
+	}
+
+	if err = os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
+
+// Host returns the host portion of a database connection string, so that
+// credentials aren't written to the report.
+func Host(connStr string) string {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
+
+func latency(s histogram.Summary) Latency {
+	return Latency{
+		Count:  s.Count,
+		P50MS:  ms(s.P50),
+		P90MS:  ms(s.P90),
+		P99MS:  ms(s.P99),
+		P999MS: ms(s.P999),
+		MaxMS:  ms(s.Max),
+	}
+}
+
+// timestamp returns nil for zero times, so they're omitted from the report.
+func timestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
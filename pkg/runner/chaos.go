@@ -2,17 +2,25 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"path/filepath"
+	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/codingconcepts/db-chaos/pkg/proxy"
 	"github.com/codingconcepts/db-chaos/pkg/repo"
 	"github.com/fatih/color"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -25,142 +33,511 @@ var (
 	yellow = color.RGB(255, 240, 133).SprintFunc()
 )
 
+// Event records the lifetime of a single fault.
+type Event struct {
+	Experiment string
+	Kind       string
+	Name       string
+	Applied    time.Time
+	Deleted    time.Time
+
+	// Injected and Recovered are when the fault was reported as injected
+	// into, and recovered from, every target. They're zero for chaos run by
+	// a workflow.
+	Injected  time.Time
+	Recovered time.Time
+
+	// Restarted holds the target pods that restarted or were replaced while
+	// the chaos was active.
+	Restarted []string
+
+	// Running and Ready are when the target pods were next Running, and when
+	// the database next reported ready, after recovering from a fault that
+	// took down the pods' nodes. They're zero for other faults.
+	Running time.Time
+	Ready   time.Time
+}
+
+// ChaosOptions configures where chaos experiments are run and which pods
+// they target.
+type ChaosOptions struct {
+	// Namespace is the namespace containing the database pods.
+	Namespace string
+
+	// ChaosNamespace is the namespace Chaos Mesh objects are created in.
+	ChaosNamespace string
+
+	// Selector is a label query that database pods must match.
+	Selector string
+
+	// StatefulSet, if set, limits targets to pods owned by the named
+	// StatefulSet.
+	StatefulSet string
+
+	// ReadyTimeout is the amount of time to wait for the database to become
+	// ready between experiments.
+	ReadyTimeout time.Duration
+
+	// ReplicationFactor is the number of replicas the database keeps of
+	// each piece of data. If set, the number of pods faulted at once by
+	// concurrent experiments is limited to a minority of it.
+	ReplicationFactor int
+
+	// MaxFaulted, if set, overrides the limit on the number of pods faulted
+	// at once that's derived from the replication factor.
+	MaxFaulted int
+
+	// InjectTimeout is the amount of time to wait for a fault to be reported
+	// as injected or recovered.
+	InjectTimeout time.Duration
+
+	// Pods, if set, are used as the database pods instead of discovering
+	// them, which allows a scenario to be rendered without a cluster.
+	Pods []string
+
+	// DryRun creates a runner that only renders the scenario, which doesn't
+	// need a cluster if Pods are given.
+	DryRun bool
+
+	// Workflow compiles the scenario into a Chaos Mesh Workflow that's
+	// submitted once and followed, rather than applying each experiment.
+	Workflow bool
+
+	// ResumeWorkflow, if set, is the name or run ID of a workflow submitted
+	// by an earlier run, which is followed instead of running the scenario.
+	ResumeWorkflow string
+
+	// Backend is the fault injection backend to use (see BackendChaosMesh
+	// and BackendKubernetes).
+	Backend string
+
+	// Proxies are the built-in proxies in front of each database node, for
+	// the proxy backend, keyed by node name (see StartProxies).
+	Proxies map[string]*proxy.Proxy
+
+	// HelperImage is the image of the privileged pods started on nodes to
+	// inject faults that can't be injected through the Kubernetes API, such
+	// as freezing a pod's processes. It must provide a shell.
+	HelperImage string
+
+	// Seed seeds every random choice the runner makes, such as nemesis
+	// schedules and random partitions. A random seed is used if zero.
+	Seed uint64
+}
+
 type ChaosRunner struct {
 	repo           repo.Repo
-	ns             string
-	chaosNS        string
-	downDuration   time.Duration
-	readyTimeout   time.Duration
+	scenario       scenario.Scenario
+	opts           ChaosOptions
 	notify         chan<- string
 	kubeRestConfig *rest.Config
 	kubeClient     *kubernetes.Clientset
 	kubeClientDyn  *dynamic.DynamicClient
+	injector       Injector
+	runID          string
+	pods           []string
+	seed           uint64
+	rngMu          sync.Mutex
+	rng            *rand.Rand
+	schedule       []NemesisStep
+	workflow       *workflowBuilder
+
+	// rendering is set while the objects the scenario would create are
+	// collected into rendered, rather than applied.
+	rendering bool
+	rendered  []chaos.Object
+
+	// concurrent is the number of concurrent groups running, and faulted the
+	// number of active faults affecting each pod.
+	concurrent  atomic.Int32
+	faultedMu   sync.Mutex
+	faultedCond *sync.Cond
+	faulted     map[string]int
+
+	// active holds a function to remove each fault that's been injected
+	// but not yet recovered from.
+	activeMu sync.Mutex
+	active   map[string]func() error
+	stopped  bool
+
+	timelineMu sync.Mutex
+	timeline   []Event
 }
 
-func NewChaosRunner(repo repo.Repo, ns, chaosNS string, downDuration, readyTimeout time.Duration, notify chan<- string) (*ChaosRunner, error) {
+func NewChaosRunner(repo repo.Repo, s scenario.Scenario, opts ChaosOptions, notify chan<- string) (*ChaosRunner, error) {
+	// A cluster isn't needed to render a scenario against a given set of
+	// pods or to inject faults through a proxy or into local nodes, so it's
+	// only required once it's used.
 	restConfig, kubeClient, dynClient, err := createKubernetesClient()
-	if err != nil {
+	if err != nil && !(opts.DryRun && len(opts.Pods) > 0) && !opts.clusterless() {
 		return nil, fmt.Errorf("creating kubernetes client: %w", err)
 	}
 
-	return &ChaosRunner{
+	seed := opts.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	r := &ChaosRunner{
 		repo:           repo,
-		ns:             ns,
-		chaosNS:        chaosNS,
-		downDuration:   downDuration,
-		readyTimeout:   readyTimeout,
+		scenario:       s,
+		opts:           opts,
 		notify:         notify,
 		kubeRestConfig: restConfig,
 		kubeClient:     kubeClient,
 		kubeClientDyn:  dynClient,
-	}, nil
+		runID:          newRunID(),
+		seed:           seed,
+		rng:            rand.New(rand.NewPCG(seed, 0)),
+		active:         map[string]func() error{},
+		faulted:        map[string]int{},
+	}
+	r.faultedCond = sync.NewCond(&r.faultedMu)
+
+	if r.injector, err = r.newInjector(); err != nil {
+		return nil, err
+	}
+
+	// Reject experiments the backend can't run before the workload starts,
+	// rather than part way through the run.
+	if err = checkSupported(r.injector, s.Experiments); err != nil {
+		return nil, err
+	}
+
+	return r, nil
 }
 
-func (r *ChaosRunner) Run() error {
-	pods, err := r.getPods()
-	if err != nil {
-		return fmt.Errorf("fetching pods: %w", err)
+// RunID returns the value of the run ID label applied to every Chaos Mesh
+// object created by the runner.
+func (r *ChaosRunner) RunID() string {
+	return r.runID
+}
+
+// Seed returns the seed used for the runner's random choices, which can be
+// passed back in to replay a run.
+func (r *ChaosRunner) Seed() uint64 {
+	return r.seed
+}
+
+func (r *ChaosRunner) Run() (err error) {
+	// Don't leave chaos behind if an experiment fails part way through.
+	defer func() {
+		if cleanupErr := r.Cleanup(); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+	}()
+
+	if _, err = r.Pods(); err != nil {
+		return err
 	}
 
-	log.Printf("[%s] pods: %v", yellow("chaos"), pods)
+	log.Printf("[%s] run id: %s", yellow("chaos"), r.runID)
+	log.Printf("[%s] seed: %d", yellow("chaos"), r.seed)
+	log.Printf("[%s] pods: %v", yellow("chaos"), r.pods)
 
-	log.Printf("[%s] Running Pod Failures", yellow("chaos"))
-	if err = r.PodChaos(pods, "pod-failure"); err != nil {
-		return fmt.Errorf("running pod-failure: %w", err)
+	if r.opts.ResumeWorkflow != "" {
+		if err = r.requireChaosMesh("resuming a workflow"); err != nil {
+			return err
+		}
+		return r.resumeWorkflow(r.opts.ResumeWorkflow)
 	}
 
-	log.Printf("[%s] Running Pod Kills", yellow("chaos"))
-	if err = r.PodChaos(pods, "pod-kill"); err != nil {
-		return fmt.Errorf("running pod-kill: %w", err)
+	if r.opts.Workflow {
+		if err = r.requireChaosMesh("running a workflow"); err != nil {
+			return err
+		}
+		return r.runWorkflow()
 	}
 
-	log.Printf("[%s] Running Symmetric Network Partitions", yellow("chaos"))
-	if err = r.NetworkChaos(pods, "both"); err != nil {
-		return fmt.Errorf("running symmetric partition: %w", err)
+	return r.runScenario()
+}
+
+// runScenario runs the scenario's experiments in order, or a nemesis
+// schedule generated from them.
+func (r *ChaosRunner) runScenario() error {
+	if r.scenario.Nemesis != nil {
+		return r.runNemesis()
 	}
 
-	log.Printf("[%s] Running Asymmetric Network Partitions", yellow("chaos"))
-	if err = r.NetworkChaos(pods, "to"); err != nil {
-		return fmt.Errorf("running asymmetric partition: %w", err)
+	for _, exp := range r.scenario.Experiments {
+		targets, err := selectTargets(r.pods, exp.Targets)
+		if err != nil {
+			return fmt.Errorf("selecting targets for %s: %w", exp.Name, err)
+		}
+
+		for i := range exp.Repeat {
+			log.Printf("[%s] Running %s (%d/%d)", yellow("chaos"), exp.Name, i+1, exp.Repeat)
+			if err = r.runExperiment(targets, exp); err != nil {
+				return fmt.Errorf("running %s: %w", exp.Name, err)
+			}
+
+			if exp.Pause > 0 {
+				r.pause(exp.Pause)
+			}
+		}
 	}
 
 	return nil
 }
 
-func (r *ChaosRunner) PodChaos(pods []string, action string) error {
-	r.notify <- fmt.Sprintf("pod-chaos-%s", action)
+func (r *ChaosRunner) runExperiment(pods []string, exp scenario.Experiment) error {
+	// Clock skew and failure domain experiments report each offset or
+	// domain separately.
+	if exp.Type == scenario.TypeClockSkew {
+		return r.ClockSkew(pods, exp)
+	}
+	if exp.Domain != "" {
+		return r.FailureDomainChaos(pods, exp)
+	}
+
+	return r.during(exp.Name, func() error {
+		return r.dispatch(pods, exp)
+	})
+}
+
+// during notifies the workload that an experiment is running for as long as
+// fn is running, so that its results are recorded separately.
+func (r *ChaosRunner) during(name string, fn func() error) error {
+	if r.rendering {
+		return fn()
+	}
+	if r.workflow != nil {
+		return r.workflow.experiment(name, fn)
+	}
+
+	r.notify <- name
 	defer func() { r.notify <- "" }()
 
-	for _, pod := range pods {
-		if err := r.waitForReady(); err != nil {
-			return fmt.Errorf("waiting for ready: %w", err)
-		}
+	return fn()
+}
+
+// pause waits between experiments, or adds a Suspend step when compiling a
+// workflow.
+func (r *ChaosRunner) pause(d time.Duration) {
+	if r.rendering {
+		return
+	}
+	if r.workflow != nil {
+		r.workflow.suspend(d)
+		return
+	}
+
+	log.Printf("[%s] pausing for %s", yellow("chaos"), d)
+	time.Sleep(d)
+}
+
+func (r *ChaosRunner) dispatch(pods []string, exp scenario.Experiment) error {
+	switch exp.Type {
+	case scenario.TypePodFailure, scenario.TypePodKill:
+		return r.PodChaos(pods, exp)
+
+	case scenario.TypePartition:
+		return r.NetworkChaos(pods, exp)
+
+	case scenario.TypeNetworkDelay, scenario.TypeNetworkLoss, scenario.TypeNetworkDuplicate, scenario.TypeNetworkCorrupt, scenario.TypeNetworkBandwidth:
+		return r.NetworkDegradation(pods, exp)
 
-		chaos := chaos.MakePodChaos(pod, r.ns, r.chaosNS, action, r.downDuration)
+	case scenario.TypeDiskLatency, scenario.TypeDiskFault, scenario.TypeDiskAttrOverride, scenario.TypeDiskMistake:
+		return r.DiskChaos(pods, exp)
 
-		delete, err := applyExperiment(r.kubeRestConfig, r.kubeClientDyn, &chaos)
+	case scenario.TypeStress:
+		return r.StressChaos(pods, exp)
+
+	case scenario.TypeDNSError, scenario.TypeDNSRandom:
+		return r.DNSChaos(pods, exp)
+
+	case scenario.TypePodEvict, scenario.TypeNodeDrain, scenario.TypeNodeCordon, scenario.TypeKubeletStop, scenario.TypePodFreeze,
+		scenario.TypeNetworkTimeout, scenario.TypeNetworkReset, scenario.TypeNetworkDrop, scenario.TypeNetworkHalfOpen, scenario.TypeNetworkSlowClose:
+		return r.eachTarget(pods, exp, noObject)
+
+	case scenario.TypeScale:
+		return r.Scale(exp)
+
+	case scenario.TypeConcurrent:
+		return r.Concurrent(pods, exp)
+
+	default:
+		return fmt.Errorf("unsupported experiment type: %q", exp.Type)
+	}
+}
+
+func (r *ChaosRunner) PodChaos(pods []string, exp scenario.Experiment) error {
+	return r.eachTarget(pods, exp, func(name string, pods []string) (chaos.Object, error) {
+		chaos := chaos.MakePodChaos(name, pods, r.opts.Namespace, r.opts.ChaosNamespace, exp.Type, exp.Duration)
+		return &chaos, nil
+	})
+}
+
+// eachTarget injects the chaos returned by build into every target at once
+// or into each target in turn, depending on the experiment's mode.
+func (r *ChaosRunner) eachTarget(pods []string, exp scenario.Experiment, build func(name string, pods []string) (chaos.Object, error)) error {
+	if exp.Mode == scenario.ModeAll {
+		obj, err := build(exp.Name, pods)
 		if err != nil {
-			return fmt.Errorf("applying experiment: %w", err)
+			return err
 		}
-		log.Printf("[%s] applied chaos: %s", yellow("chaos"), chaos.Metadata.Name)
+		return r.inject(Fault{Name: exp.Name, Experiment: exp, Pods: pods, Object: obj})
+	}
 
-		time.Sleep(r.downDuration)
+	// Faults are named after the experiment as well as the pod, so that
+	// experiments of the same type in a concurrent group don't collide.
+	for _, pod := range pods {
+		name := fmt.Sprintf("%s-%s", exp.Name, pod)
+		obj, err := build(name, []string{pod})
+		if err != nil {
+			return err
+		}
 
-		if err := delete(); err != nil {
-			return fmt.Errorf("applying experiment: %w", err)
+		if err = r.inject(Fault{Name: name, Experiment: exp, Pods: []string{pod}, Object: obj}); err != nil {
+			return err
 		}
-		log.Printf("[%s] deleted chaos: %s", yellow("chaos"), chaos.Metadata.Name)
 	}
 
 	return nil
 }
 
-func (r *ChaosRunner) NetworkChaos(pods []string, action string) error {
-	r.notify <- fmt.Sprintf("network-chaos-%s", action)
-	defer func() { r.notify <- "" }()
+func (r *ChaosRunner) NetworkChaos(pods []string, exp scenario.Experiment) error {
+	parts, err := r.partitions(pods, "pods", exp.Topology)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range parts {
+		log.Printf("[%s] partitioning %v from %v", yellow("chaos"), p.a, p.b)
+		if err = r.partition(exp, fmt.Sprintf("%s-%s", exp.Name, p.name), p.a, p.b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// partition separates two groups of pods.
+func (r *ChaosRunner) partition(exp scenario.Experiment, name string, a, b []string) error {
+	chaos := chaos.MakePartition(name, a, b, r.opts.Namespace, r.opts.ChaosNamespace, exp.Direction)
+	return r.inject(Fault{Name: name, Experiment: exp, Pods: a, Peers: b, Object: &chaos})
+}
+
+// partitions is safe to call from concurrent experiments.
+func (r *ChaosRunner) partitions(pods []string, noun, topology string) ([]partition, error) {
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+
+	return partitions(pods, noun, topology, r.rng)
+}
 
-	partitionPairs := getPairCombinationsSlices(pods)
+// inject waits for the database to be ready, injects a fault and removes it
+// again once the experiment's duration has elapsed.
+func (r *ChaosRunner) inject(f Fault) error {
+	if r.rendering || r.workflow != nil {
+		if f.Object == nil {
+			return fmt.Errorf("%s experiments can only be run by applying them directly", f.Experiment.Type)
+		}
+		if r.rendering {
+			r.rendered = append(r.rendered, f.Object)
+			return nil
+		}
+		return r.workflow.inject(f.Object, f.Experiment.Duration)
+	}
 
-	for _, pair := range partitionPairs {
+	if r.concurrent.Load() == 0 {
 		if err := r.waitForReady(); err != nil {
 			return fmt.Errorf("waiting for ready: %w", err)
 		}
+	}
 
-		chaos := chaos.MakePartition(pair[0], pair[1], r.ns, r.chaosNS, action)
+	release := r.reserve(f.faulted())
+	defer release()
 
-		delete, err := applyExperiment(r.kubeRestConfig, r.kubeClientDyn, &chaos)
-		if err != nil {
-			return fmt.Errorf("applying experiment: %w", err)
-		}
-		log.Printf("[%s] applied chaos: %s", yellow("chaos"), chaos.Metadata.Name)
+	before, err := r.podStates(f.affected())
+	if err != nil {
+		return fmt.Errorf("fetching pod states: %w", err)
+	}
+
+	injection, err := r.start(f)
+	if err != nil {
+		return fmt.Errorf("injecting fault: %w", err)
+	}
+
+	event := Event{
+		Experiment: f.Experiment.Name,
+		Kind:       injection.Kind,
+		Name:       f.Name,
+		Applied:    time.Now(),
+	}
+	log.Printf("[%s] applied chaos: %s", yellow("chaos"), event.Name)
+
+	if event.Injected, err = injection.Wait(); err != nil {
+		return err
+	}
+	log.Printf("[%s] injected chaos: %s", yellow("chaos"), event.Name)
+
+	time.Sleep(f.Experiment.Duration)
 
-		time.Sleep(r.downDuration)
+	event.Deleted = time.Now()
+	if event.Recovered, err = injection.Recover(); err != nil {
+		return err
+	}
+	r.untrack(f.key(injection))
+	log.Printf("[%s] recovered chaos: %s", yellow("chaos"), event.Name)
 
-		if err := delete(); err != nil {
-			return fmt.Errorf("applying experiment: %w", err)
+	if f.Experiment.IsNodeFault() {
+		if event.Running, err = r.waitForRunning(f.Pods); err != nil {
+			return fmt.Errorf("waiting for pods to run: %w", err)
+		}
+		if err = r.waitForReady(); err != nil {
+			return fmt.Errorf("waiting for ready: %w", err)
 		}
-		log.Printf("[%s] deleted chaos: %s", yellow("chaos"), chaos.Metadata.Name)
+		event.Ready = time.Now()
+		log.Printf("[%s] pods running after %s, database ready after %s: %s", yellow("chaos"), event.Running.Sub(event.Recovered), event.Ready.Sub(event.Recovered), event.Name)
+	}
+
+	after, err := r.podStates(f.affected())
+	if err != nil {
+		return fmt.Errorf("fetching pod states: %w", err)
 	}
 
+	event.Restarted = restarted(before, after)
+	if len(event.Restarted) > 0 {
+		log.Printf("[%s] pods restarted during chaos: %v", yellow("chaos"), event.Restarted)
+	}
+
+	r.record(event)
 	return nil
 }
 
-func getPairCombinationsSlices(strings []string) [][2]string {
-	var pairs [][2]string
+func (r *ChaosRunner) record(event Event) {
+	r.timelineMu.Lock()
+	defer r.timelineMu.Unlock()
 
-	for i := range strings {
-		for j := range strings {
-			if i != j {
-				pairs = append(pairs, [2]string{strings[i], strings[j]})
-			}
-		}
+	r.timeline = append(r.timeline, event)
+}
+
+// Timeline returns the faults injected so far, in the order they were
+// recovered from.
+func (r *ChaosRunner) Timeline() []Event {
+	r.timelineMu.Lock()
+	defer r.timelineMu.Unlock()
+
+	return slices.Clone(r.timeline)
+}
+
+// selectTargets returns the pods named in targets, or all pods if no targets
+// have been specified.
+func selectTargets(pods, targets []string) ([]string, error) {
+	if len(targets) == 0 {
+		return pods, nil
 	}
 
-	return pairs
+	if missing := lo.Without(targets, pods...); len(missing) > 0 {
+		return nil, fmt.Errorf("targets not found: %v", missing)
+	}
+
+	return targets, nil
 }
 
 func (r *ChaosRunner) waitForReady() error {
-	timeout := time.Tick(r.readyTimeout)
+	timeout := time.Tick(r.opts.ReadyTimeout)
 	check := time.Tick(time.Second * 5)
 
 	for {
@@ -186,6 +563,26 @@ func (r *ChaosRunner) waitForReady() error {
 	}
 }
 
+// waitForRunning waits for every pod to be Running, which for pods that
+// were evicted means their replacements have been scheduled and started.
+func (r *ChaosRunner) waitForRunning(pods []string) (time.Time, error) {
+	return waitFor(r.opts.ReadyTimeout, fmt.Sprintf("pods %v to be running", pods), func() (bool, error) {
+		for _, name := range pods {
+			pod, err := r.kubeClient.CoreV1().Pods(r.opts.Namespace).Get(context.Background(), name, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				return false, nil
+			}
+			if err != nil {
+				return false, fmt.Errorf("getting pod %s: %w", name, err)
+			}
+			if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
 func createKubernetesClient() (*rest.Config, *kubernetes.Clientset, *dynamic.DynamicClient, error) {
 	var config *rest.Config
 	var err error
@@ -217,15 +614,62 @@ func createKubernetesClient() (*rest.Config, *kubernetes.Clientset, *dynamic.Dyn
 	return config, client, dyn, nil
 }
 
+// Pods returns the database pods that experiments target, discovering them
+// the first time it's called. Calling it before the workload starts allows
+// a run to fail fast if no pods match.
+func (r *ChaosRunner) Pods() ([]string, error) {
+	if r.pods != nil {
+		return r.pods, nil
+	}
+
+	pods, err := r.getPods()
+	if err != nil {
+		return nil, fmt.Errorf("fetching pods: %w", err)
+	}
+
+	r.pods = pods
+	return r.pods, nil
+}
+
 func (r *ChaosRunner) getPods() ([]string, error) {
+	if len(r.opts.Pods) > 0 {
+		return r.opts.Pods, nil
+	}
+
+	// Proxied and local nodes stand in for pods.
+	if r.opts.Backend == BackendLocal && r.scenario.Local != nil {
+		return lo.Map(r.scenario.Local.Nodes, func(n scenario.LocalNode, _ int) string {
+			return n.Name
+		}), nil
+	}
+	if r.opts.clusterless() && r.scenario.Proxy != nil {
+		return lo.Map(r.scenario.Proxy.Nodes, func(n scenario.ProxyNode, _ int) string {
+			return n.Name
+		}), nil
+	}
+
 	timeout, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	pods, err := r.kubeClient.CoreV1().Pods(r.ns).List(timeout, metav1.ListOptions{})
+	listOptions := metav1.ListOptions{
+		LabelSelector: r.opts.Selector,
+	}
+
+	pods, err := r.kubeClient.CoreV1().Pods(r.opts.Namespace).List(timeout, listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods: %v", err)
 	}
 
+	if r.opts.StatefulSet != "" {
+		pods.Items = lo.Filter(pods.Items, func(p v1.Pod, _ int) bool {
+			return ownedByStatefulSet(p, r.opts.StatefulSet)
+		})
+	}
+
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found in namespace %q matching selector %q and statefulset %q", r.opts.Namespace, r.opts.Selector, r.opts.StatefulSet)
+	}
+
 	podNames := lo.Map(pods.Items, func(p v1.Pod, _ int) string {
 		return p.Name
 	})
@@ -233,3 +677,60 @@ func (r *ChaosRunner) getPods() ([]string, error) {
 	sort.Strings(podNames)
 	return podNames, nil
 }
+
+func ownedByStatefulSet(pod v1.Pod, name string) bool {
+	return lo.ContainsBy(pod.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "StatefulSet" && ref.Name == name
+	})
+}
+
+// podState captures enough about a pod to tell whether it has restarted.
+type podState struct {
+	uid      string
+	restarts int32
+}
+
+// podStates returns the state of each of the given pods, omitting any that
+// don't currently exist. Without a cluster, no states are returned.
+func (r *ChaosRunner) podStates(pods []string) (map[string]podState, error) {
+	if r.kubeClient == nil || r.opts.clusterless() {
+		return nil, nil
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	states := map[string]podState{}
+	for _, name := range pods {
+		pod, err := r.kubeClient.CoreV1().Pods(r.opts.Namespace).Get(timeout, name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting pod %s: %w", name, err)
+		}
+
+		state := podState{uid: string(pod.UID)}
+		for _, cs := range pod.Status.ContainerStatuses {
+			state.restarts += cs.RestartCount
+		}
+		states[name] = state
+	}
+
+	return states, nil
+}
+
+// restarted returns the pods whose containers restarted, or which were
+// replaced, between two sets of pod states.
+func restarted(before, after map[string]podState) []string {
+	var pods []string
+	for name, b := range before {
+		a, ok := after[name]
+		if !ok || a.uid != b.uid || a.restarts > b.restarts {
+			pods = append(pods, name)
+		}
+	}
+
+	sort.Strings(pods)
+	return pods
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// chaosMeshInjector injects faults by creating Chaos Mesh objects.
+type chaosMeshInjector struct {
+	restConfig *rest.Config
+	dyn        *dynamic.DynamicClient
+	labels     map[string]string
+	timeout    time.Duration
+
+	// native injects the faults that don't need Chaos Mesh.
+	native *kubernetesInjector
+}
+
+func (i *chaosMeshInjector) Supports(expType string) bool {
+	return !scenario.Experiment{Type: expType}.IsConnectionFault()
+}
+
+func (i *chaosMeshInjector) Validate(exp scenario.Experiment) error {
+	return i.native.Validate(exp)
+}
+
+func (i *chaosMeshInjector) Inject(f Fault) (Injection, error) {
+	if f.Object == nil {
+		return i.native.Inject(f)
+	}
+
+	dr, err := applyExperiment(i.restConfig, i.dyn, f.Object, i.labels)
+	if err != nil {
+		return Injection{}, fmt.Errorf("applying experiment: %w", err)
+	}
+	name := f.Object.GetMetadata().Name
+
+	return Injection{
+		Kind: f.Object.GetKind(),
+		Wait: func() (time.Time, error) {
+			return waitForInjection(dr, name, i.timeout)
+		},
+		Recover: func() (time.Time, error) {
+			if err := dr.Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return time.Time{}, fmt.Errorf("deleting experiment: %w", err)
+			}
+			return waitForRecovery(dr, name, i.timeout)
+		},
+	}, nil
+}
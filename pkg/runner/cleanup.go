@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "db-chaos"
+	runIDLabel     = "db-chaos/run-id"
+)
+
+// chaosResources are the Chaos Mesh resources that db-chaos creates, and
+// therefore the resources swept by Sweep.
+var chaosResources = []schema.GroupVersionResource{
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "podchaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "networkchaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "iochaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "timechaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "stresschaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "dnschaos"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "workflows"},
+	{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "schedules"},
+}
+
+var errStopped = errors.New("chaos runner has been stopped")
+
+func newRunID() string {
+	return fmt.Sprintf("%s-%04x", time.Now().UTC().Format("20060102-150405"), rand.IntN(0x10000))
+}
+
+func (r *ChaosRunner) labels() map[string]string {
+	return map[string]string{
+		managedByLabel: managedByValue,
+		runIDLabel:     r.runID,
+	}
+}
+
+// start injects a fault and tracks it until it's untracked, so it can be
+// recovered from by Cleanup if the run is interrupted.
+func (r *ChaosRunner) start(f Fault) (Injection, error) {
+	r.activeMu.Lock()
+	defer r.activeMu.Unlock()
+
+	if r.stopped {
+		return Injection{}, errStopped
+	}
+
+	injection, err := r.injector.Inject(f)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	r.active[f.key(injection)] = func() error {
+		_, err := injection.Recover()
+		return err
+	}
+	return injection, nil
+}
+
+func (r *ChaosRunner) untrack(key string) {
+	r.activeMu.Lock()
+	defer r.activeMu.Unlock()
+
+	delete(r.active, key)
+}
+
+// Cleanup removes any faults that are still active and prevents any further
+// faults from being injected. It's safe to call from another goroutine while
+// experiments are running.
+func (r *ChaosRunner) Cleanup() error {
+	r.activeMu.Lock()
+	defer r.activeMu.Unlock()
+
+	r.stopped = true
+
+	var errs []error
+	for key, remove := range r.active {
+		if err := remove(); err != nil {
+			errs = append(errs, fmt.Errorf("removing %s: %w", key, err))
+			continue
+		}
+
+		log.Printf("[%s] cleaned up chaos: %s", yellow("chaos"), key)
+		delete(r.active, key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Sweep deletes all of the Chaos Mesh objects in the chaos namespace that
+// were created by db-chaos, optionally limited to those created by a given
+// run. Faults injected by the Kubernetes backend are also removed from the
+// database namespace: NetworkPolicies and helper pods are deleted, nodes
+// uncordoned and StatefulSets scaled back up. It returns the number of objects cleaned up.
+func Sweep(chaosNS, namespace, runID string) (int, error) {
+	_, client, dynClient, err := createKubernetesClient()
+	if err != nil {
+		return 0, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	selector := fmt.Sprintf("%s=%s", managedByLabel, managedByValue)
+	if runID != "" {
+		selector += fmt.Sprintf(",%s=%s", runIDLabel, runID)
+	}
+
+	var deleted int
+	for _, gvr := range chaosResources {
+		dr := dynClient.Resource(gvr).Namespace(chaosNS)
+
+		list, err := dr.List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			// The CRD may not be installed.
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return deleted, fmt.Errorf("listing %s: %w", gvr.Resource, err)
+		}
+
+		for _, item := range list.Items {
+			if err = dr.Delete(context.Background(), item.GetName(), metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return deleted, fmt.Errorf("deleting %s %s: %w", gvr.Resource, item.GetName(), err)
+			}
+
+			log.Printf("[%s] deleted %s: %s", yellow("chaos"), gvr.Resource, item.GetName())
+			deleted++
+		}
+	}
+
+	swept, err := sweepKubernetes(client, namespace, runID)
+	return deleted + swept, err
+}
+
+// sweepKubernetes removes the faults injected by the Kubernetes backend.
+// Nodes and StatefulSets aren't owned by db-chaos, so they're only labelled
+// with the run ID while they're faulted.
+func sweepKubernetes(client *kubernetes.Clientset, namespace, runID string) (int, error) {
+	var swept int
+
+	selector := fmt.Sprintf("%s=%s", managedByLabel, managedByValue)
+	runSelector := runIDLabel
+	if runID != "" {
+		selector += fmt.Sprintf(",%s=%s", runIDLabel, runID)
+		runSelector += "=" + runID
+	}
+
+	policies := client.NetworkingV1().NetworkPolicies(namespace)
+	policyList, err := policies.List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return swept, fmt.Errorf("listing network policies: %w", err)
+	}
+
+	for _, policy := range policyList.Items {
+		if err = policies.Delete(context.Background(), policy.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return swept, fmt.Errorf("deleting network policy %s: %w", policy.Name, err)
+		}
+
+		log.Printf("[%s] deleted networkpolicies: %s", yellow("chaos"), policy.Name)
+		swept++
+	}
+
+	// Deleting a helper pod undoes its fault.
+	pods := client.CoreV1().Pods(namespace)
+	podList, err := pods.List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return swept, fmt.Errorf("listing helper pods: %w", err)
+	}
+
+	for _, pod := range podList.Items {
+		if err = pods.Delete(context.Background(), pod.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return swept, fmt.Errorf("deleting helper pod %s: %w", pod.Name, err)
+		}
+
+		log.Printf("[%s] deleted helper pod: %s", yellow("chaos"), pod.Name)
+		swept++
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{LabelSelector: runSelector})
+	if err != nil {
+		return swept, fmt.Errorf("listing nodes: %w", err)
+	}
+
+	for _, node := range nodes.Items {
+		if err = uncordon(client, node.Name); err != nil {
+			return swept, err
+		}
+		swept++
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: runSelector})
+	if err != nil {
+		return swept, fmt.Errorf("listing statefulsets: %w", err)
+	}
+
+	for _, sts := range statefulSets.Items {
+		replicas, err := strconv.Atoi(sts.Annotations[replicasAnnotation])
+		if err != nil {
+			return swept, fmt.Errorf("reading original replicas of statefulset %s: %w", sts.Name, err)
+		}
+
+		if err = setScale(client, namespace, sts.Name, int32(replicas)); err != nil {
+			return swept, err
+		}
+		if err = unmarkStatefulSet(client, namespace, sts.Name); err != nil {
+			return swept, err
+		}
+
+		log.Printf("[%s] restored statefulset %s to %d replicas", yellow("chaos"), sts.Name, replicas)
+		swept++
+	}
+
+	return swept, nil
+}
+
+func activeKey(obj chaos.Object) string {
+	return fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetMetadata().Name)
+}
+
+func (f Fault) key(injection Injection) string {
+	return fmt.Sprintf("%s/%s", injection.Kind, f.Name)
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// ClockSkew shifts the clocks of the target pods by each of the experiment's
+// offsets in turn. The results of each offset are recorded separately, so
+// that the effect of skewing forwards and backwards can be compared.
+func (r *ChaosRunner) ClockSkew(pods []string, exp scenario.Experiment) error {
+	for i, offset := range exp.Clock.Offsets {
+		sub := exp
+		sub.Name = fmt.Sprintf("%s %s", exp.Name, formatOffset(offset))
+
+		err := r.during(sub.Name, func() error {
+			if exp.Mode == scenario.ModeAll {
+				name := fmt.Sprintf("%s-%d", exp.Name, i)
+				chaos := chaos.MakeTimeChaos(name, pods, r.opts.Namespace, r.opts.ChaosNamespace, offset, exp.Clock.ClockIDs, exp.Duration)
+				return r.inject(Fault{Name: name, Experiment: sub, Pods: pods, Object: &chaos})
+			}
+
+			for _, pod := range pods {
+				name := fmt.Sprintf("%s-%s-%d", exp.Name, pod, i)
+				chaos := chaos.MakeTimeChaos(name, []string{pod}, r.opts.Namespace, r.opts.ChaosNamespace, offset, exp.Clock.ClockIDs, exp.Duration)
+
+				if err := r.inject(Fault{Name: name, Experiment: sub, Pods: []string{pod}, Object: &chaos}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("skewing clock by %s: %w", offset, err)
+		}
+	}
+
+	return nil
+}
+
+// formatOffset returns a clock offset with an explicit sign.
+func formatOffset(offset time.Duration) string {
+	if offset > 0 {
+		return "+" + offset.String()
+	}
+	return offset.String()
+}
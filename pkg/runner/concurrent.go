@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+)
+
+// Concurrent runs a group of experiments at the same time, recording their
+// results together under the group's name. Each experiment selects its
+// targets from the group's targets.
+func (r *ChaosRunner) Concurrent(pods []string, exp scenario.Experiment) error {
+	children := make([]scenario.Experiment, len(exp.Experiments))
+	targets := make([][]string, len(exp.Experiments))
+
+	for i, child := range exp.Experiments {
+		child.Name = fmt.Sprintf("%s-%s", exp.Name, child.Name)
+
+		childTargets, err := selectTargets(pods, child.Targets)
+		if err != nil {
+			return fmt.Errorf("selecting targets for %s: %w", child.Name, err)
+		}
+
+		children[i], targets[i] = child, childTargets
+	}
+
+	if r.rendering {
+		for i, child := range children {
+			if err := r.runChild(targets[i], child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if r.workflow != nil {
+		return r.workflow.group(exp.Name, chaos.TemplateParallel, func() error {
+			for i, child := range children {
+				err := r.workflow.group(child.Name, chaos.TemplateSerial, func() error {
+					return r.runChild(targets[i], child)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	// Faults in the group don't wait for the database to be ready, as the
+	// other faults in the group may be keeping it unavailable.
+	if err := r.waitForReady(); err != nil {
+		return fmt.Errorf("waiting for ready: %w", err)
+	}
+	r.concurrent.Add(1)
+	defer r.concurrent.Add(-1)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(children))
+
+	for i, child := range children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.runChild(targets[i], child)
+		}()
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runChild runs an experiment that's part of a concurrent group.
+func (r *ChaosRunner) runChild(pods []string, exp scenario.Experiment) error {
+	if exp.Start > 0 {
+		r.pause(exp.Start)
+	}
+
+	for i := range exp.Repeat {
+		log.Printf("[%s] Running %s (%d/%d)", yellow("chaos"), exp.Name, i+1, exp.Repeat)
+		if err := r.dispatch(pods, exp); err != nil {
+			return fmt.Errorf("running %s: %w", exp.Name, err)
+		}
+
+		if exp.Pause > 0 {
+			r.pause(exp.Pause)
+		}
+	}
+
+	return nil
+}
+
+// maxFaulted returns the number of pods that may be faulted at once, which
+// defaults to a minority of the replication factor, so that every range
+// keeps a quorum. False is returned if there's no limit.
+func (o ChaosOptions) maxFaulted() (int, bool) {
+	switch {
+	case o.MaxFaulted > 0:
+		return o.MaxFaulted, true
+	case o.ReplicationFactor > 0:
+		return (o.ReplicationFactor - 1) / 2, true
+	default:
+		return 0, false
+	}
+}
+
+// reserve blocks until faulting the given pods would keep the number of
+// faulted pods within the limit, then marks them as faulted until release is
+// called. A fault is always allowed if no other faults are active, so that
+// experiments targeting every pod can still run on their own.
+func (r *ChaosRunner) reserve(pods []string) (release func()) {
+	limit, limited := r.opts.maxFaulted()
+
+	r.faultedMu.Lock()
+	defer r.faultedMu.Unlock()
+
+	exceeds := func() bool {
+		added := lo.CountBy(lo.Uniq(pods), func(pod string) bool { return r.faulted[pod] == 0 })
+		return limited && len(r.faulted) > 0 && len(r.faulted)+added > limit
+	}
+
+	if exceeds() {
+		log.Printf("[%s] waiting for faults on %v to end before faulting %v (max faulted %d)", yellow("chaos"), lo.Keys(r.faulted), pods, limit)
+		for exceeds() {
+			r.faultedCond.Wait()
+		}
+	}
+
+	for _, pod := range pods {
+		r.faulted[pod]++
+	}
+
+	return func() {
+		r.faultedMu.Lock()
+		defer r.faultedMu.Unlock()
+
+		for _, pod := range pods {
+			if r.faulted[pod]--; r.faulted[pod] == 0 {
+				delete(r.faulted, pod)
+			}
+		}
+		r.faultedCond.Broadcast()
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// DiskChaos injects IO faults into a volume mounted by the target pods.
+func (r *ChaosRunner) DiskChaos(pods []string, exp scenario.Experiment) error {
+	return r.eachTarget(pods, exp, func(name string, pods []string) (chaos.Object, error) {
+		chaos := makeDiskChaos(name, pods, r.opts.Namespace, r.opts.ChaosNamespace, exp)
+		return &chaos, nil
+	})
+}
+
+func makeDiskChaos(name string, pods []string, podNS, chaosNS string, exp scenario.Experiment) chaos.DiskChaos {
+	target := chaos.DiskTarget{
+		VolumePath: exp.Disk.VolumePath,
+		Path:       exp.Disk.Path,
+		Methods:    exp.Disk.Methods,
+		Percent:    exp.Disk.Percent,
+	}
+
+	switch exp.Type {
+	case scenario.TypeDiskLatency:
+		return chaos.MakeDiskLatency(name, pods, podNS, chaosNS, target, exp.Disk.Latency, exp.Duration)
+
+	case scenario.TypeDiskFault:
+		return chaos.MakeDiskFault(name, pods, podNS, chaosNS, target, exp.Disk.Errno, exp.Duration)
+
+	case scenario.TypeDiskAttrOverride:
+		attr := chaos.AttrOverride{
+			Ino:    exp.Disk.Attr.Ino,
+			Size:   exp.Disk.Attr.Size,
+			Blocks: exp.Disk.Attr.Blocks,
+			Kind:   exp.Disk.Attr.Kind,
+			Perm:   exp.Disk.Attr.Perm,
+			Nlink:  exp.Disk.Attr.Nlink,
+			UID:    exp.Disk.Attr.UID,
+			GID:    exp.Disk.Attr.GID,
+			Rdev:   exp.Disk.Attr.Rdev,
+		}
+		return chaos.MakeDiskAttrOverride(name, pods, podNS, chaosNS, target, attr, exp.Duration)
+
+	default:
+		mistake := chaos.Mistake{
+			Filling:        exp.Disk.Mistake.Filling,
+			MaxOccurrences: exp.Disk.Mistake.MaxOccurrences,
+			MaxLength:      exp.Disk.Mistake.MaxLength,
+		}
+		return chaos.MakeDiskMistake(name, pods, podNS, chaosNS, target, mistake, exp.Duration)
+	}
+}
@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// DNSChaos makes DNS lookups from the target pods fail or return random IPs.
+func (r *ChaosRunner) DNSChaos(pods []string, exp scenario.Experiment) error {
+	action := strings.TrimPrefix(exp.Type, "dns-")
+
+	var patterns []string
+	if exp.DNS != nil {
+		patterns = exp.DNS.Patterns
+	}
+
+	return r.eachTarget(pods, exp, func(name string, pods []string) (chaos.Object, error) {
+		chaos := chaos.MakeDNSChaos(name, pods, r.opts.Namespace, r.opts.ChaosNamespace, action, patterns, exp.Duration)
+		return &chaos, nil
+	})
+}
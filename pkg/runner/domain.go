@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Well-known node labels that failure domains can be referred to by.
+var domainLabels = map[string]string{
+	scenario.DomainZone:   "topology.kubernetes.io/zone",
+	scenario.DomainRegion: "topology.kubernetes.io/region",
+	scenario.DomainNode:   "kubernetes.io/hostname",
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// FailureDomainChaos groups the target pods by a label of the nodes they're
+// scheduled on and runs the experiment against whole failure domains,
+// recording the results of each domain (or pair of domains) separately.
+func (r *ChaosRunner) FailureDomainChaos(pods []string, exp scenario.Experiment) error {
+	domains, err := r.failureDomains(pods, exp.Domain)
+	if err != nil {
+		return fmt.Errorf("fetching failure domains: %w", err)
+	}
+
+	names := lo.Keys(domains)
+	slices.Sort(names)
+	log.Printf("[%s] failure domains: %v", yellow("chaos"), domains)
+
+	switch {
+	case exp.Type == scenario.TypePartition:
+		parts, err := r.partitions(names, "domains", exp.Topology)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parts {
+			sub := domainExperiment(exp, p.name)
+			a := lo.FlatMap(p.a, func(d string, _ int) []string { return domains[d] })
+			b := lo.FlatMap(p.b, func(d string, _ int) []string { return domains[d] })
+
+			err = r.during(sub.Name, func() error {
+				log.Printf("[%s] partitioning %v from %v", yellow("chaos"), p.a, p.b)
+				return r.partition(sub, sub.Name, a, b)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+	case exp.IsNetworkDegradation():
+		for _, pair := range getPairCombinationsSlices(names) {
+			sub := domainExperiment(exp, pair[0], pair[1])
+			sub.Peers = domains[pair[1]]
+
+			err = r.during(sub.Name, func() error {
+				log.Printf("[%s] degrading network from %s to %s", yellow("chaos"), pair[0], pair[1])
+				return r.dispatch(domains[pair[0]], sub)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		for _, name := range names {
+			sub := domainExperiment(exp, name)
+
+			err = r.during(sub.Name, func() error {
+				return r.dispatch(domains[name], sub)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// domainExperiment returns a copy of an experiment that targets every pod in
+// a failure domain at once, named after the domain(s).
+func domainExperiment(exp scenario.Experiment, domains ...string) scenario.Experiment {
+	exp.Mode = scenario.ModeAll
+	exp.Name = invalidNameChars.ReplaceAllString(strings.ToLower(strings.Join(append([]string{exp.Name}, domains...), "-")), "-")
+	return exp
+}
+
+// failureDomains groups pods by the value of a label on the nodes they're
+// scheduled on.
+func (r *ChaosRunner) failureDomains(pods []string, domain string) (map[string][]string, error) {
+	if r.kubeClient == nil {
+		return nil, fmt.Errorf("failure domains can't be resolved without a cluster")
+	}
+
+	label, ok := domainLabels[domain]
+	if !ok {
+		label = domain
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	domains := map[string][]string{}
+	nodeDomains := map[string]string{}
+
+	for _, name := range pods {
+		pod, err := r.kubeClient.CoreV1().Pods(r.opts.Namespace).Get(timeout, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting pod %s: %w", name, err)
+		}
+
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			return nil, fmt.Errorf("pod %s has not been scheduled", name)
+		}
+
+		value, ok := nodeDomains[nodeName]
+		if !ok {
+			node, err := r.kubeClient.CoreV1().Nodes().Get(timeout, nodeName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("getting node %s: %w", nodeName, err)
+			}
+
+			if value, ok = node.Labels[label]; !ok {
+				return nil, fmt.Errorf("node %s has no %q label", nodeName, label)
+			}
+			nodeDomains[nodeName] = value
+		}
+
+		domains[value] = append(domains[value], name)
+	}
+
+	return domains, nil
+}
@@ -0,0 +1,309 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// readyFile is touched by a helper pod's script to mark the pod as ready,
+// usually once its fault is in place.
+const readyFile = "/tmp/db-chaos-ready"
+
+// freezeScript stops every process in a container, found by the container
+// ID in their cgroups, and continues them when the helper pod is deleted.
+// The processes are signalled from the host's PID namespace, as a
+// container's init process ignores SIGSTOP sent from inside it.
+const freezeScript = `
+pids=$(grep -l "$CONTAINER_ID" /proc/[0-9]*/cgroup 2>/dev/null | cut -d/ -f3 | tr '\n' ' ')
+if [ -z "$pids" ]; then
+  echo "no processes found for container $CONTAINER_ID" >&2
+  exit 1
+fi
+
+trap 'kill -CONT $pids; exit 0' TERM INT
+kill -STOP $pids
+echo "froze $pids"
+touch ` + readyFile + `
+
+while true; do sleep 1 & wait $!; done
+`
+
+// kubeletScript stops the kubelet on the host, having first scheduled a
+// systemd timer to start it again after RESTART_AFTER seconds. Once the
+// kubelet has stopped, nothing on the node can be told to restart it, and
+// the timer still restarts it if db-chaos is killed. The script becomes
+// ready before stopping the kubelet, as the kubelet reports readiness.
+const kubeletScript = `
+set -e
+host="nsenter --target 1 --mount --uts --ipc --net --pid --"
+
+$host systemd-run --on-active="${RESTART_AFTER}s" systemctl start kubelet
+touch ` + readyFile + `
+sleep "$STOP_AFTER"
+
+trap 'exit 0' TERM INT
+$host systemctl stop kubelet
+echo "stopped kubelet"
+
+while true; do sleep 1 & wait $!; done
+`
+
+// kubeletStopDelay is how long the kubelet script waits between becoming
+// ready and stopping the kubelet, to give the kubelet time to report it.
+const kubeletStopDelay = time.Second * 5
+
+// stopKubelets stops the kubelet on the nodes the given pods are scheduled
+// on for the duration of the experiment. The pods keep running, but the
+// control plane loses contact with them and eventually marks the nodes as
+// NotReady.
+func (i *kubernetesInjector) stopKubelets(f Fault) (Injection, error) {
+	nodes, err := i.nodesOf(f.Pods)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	restartAfter := kubeletStopDelay + f.Experiment.Duration
+	var helpers []*v1.Pod
+	for _, node := range nodes {
+		helper := i.helperPod(f.Name+"-"+node, node, kubeletScript)
+		helper.Spec.Containers[0].Env = []v1.EnvVar{
+			{Name: "RESTART_AFTER", Value: strconv.Itoa(int(restartAfter.Seconds()))},
+			{Name: "STOP_AFTER", Value: strconv.Itoa(int(kubeletStopDelay.Seconds()))},
+		}
+		helpers = append(helpers, helper)
+	}
+
+	injection, err := i.runHelpers("KubeletStop", helpers)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	var restartAt time.Time
+	wait, remove := injection.Wait, injection.Recover
+
+	injection.Wait = func() (time.Time, error) {
+		ready, err := wait()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		// The kubelet can't report when it's stopped, so it's assumed to
+		// have stopped as scheduled.
+		restartAt = ready.Add(restartAfter)
+		time.Sleep(time.Until(ready.Add(kubeletStopDelay)))
+		return time.Now(), nil
+	}
+
+	injection.Recover = func() (time.Time, error) {
+		var recovered time.Time
+		for _, node := range nodes {
+			t, err := i.waitForKubelet(node, restartAt)
+			if err != nil {
+				return time.Time{}, err
+			}
+			recovered = t
+		}
+
+		// The helper pods can only be deleted once their kubelets are back.
+		if _, err := remove(); err != nil {
+			return time.Time{}, err
+		}
+		return recovered, nil
+	}
+
+	return injection, nil
+}
+
+// waitForKubelet waits for a node's kubelet to renew its lease after it's
+// restarted and for the node to be Ready.
+func (i *kubernetesInjector) waitForKubelet(node string, restartAt time.Time) (time.Time, error) {
+	return waitFor(time.Until(restartAt)+i.timeout, fmt.Sprintf("kubelet on node %s to restart", node), func() (bool, error) {
+		lease, err := i.client.CoordinationV1().Leases("kube-node-lease").Get(context.Background(), node, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("getting lease of node %s: %w", node, err)
+		}
+		if lease.Spec.RenewTime == nil || lease.Spec.RenewTime.Time.Before(restartAt) {
+			return false, nil
+		}
+
+		n, err := i.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("getting node %s: %w", node, err)
+		}
+		return lo.ContainsBy(n.Status.Conditions, func(c v1.NodeCondition) bool {
+			return c.Type == v1.NodeReady && c.Status == v1.ConditionTrue
+		}), nil
+	})
+}
+
+// freezePods suspends the processes of each pod's first container with
+// SIGSTOP, leaving the pod running and its connections open, like a long
+// GC pause or a stalled VM.
+func (i *kubernetesInjector) freezePods(f Fault) (Injection, error) {
+	var helpers []*v1.Pod
+	for _, name := range f.Pods {
+		pod, err := i.client.CoreV1().Pods(i.namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return Injection{}, fmt.Errorf("getting pod %s: %w", name, err)
+		}
+
+		containerID, err := mainContainerID(pod)
+		if err != nil {
+			return Injection{}, err
+		}
+
+		helper := i.helperPod(f.Name+"-"+name, pod.Spec.NodeName, freezeScript)
+		helper.Spec.Containers[0].Env = []v1.EnvVar{{Name: "CONTAINER_ID", Value: containerID}}
+		helpers = append(helpers, helper)
+	}
+
+	return i.runHelpers("Freeze", helpers)
+}
+
+// mainContainerID returns the runtime ID of a pod's first container, without
+// the runtime prefix (e.g. containerd://).
+func mainContainerID(pod *v1.Pod) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", fmt.Errorf("pod %s hasn't been scheduled", pod.Name)
+	}
+
+	container := pod.Spec.Containers[0].Name
+	status, ok := lo.Find(pod.Status.ContainerStatuses, func(s v1.ContainerStatus) bool {
+		return s.Name == container
+	})
+	if !ok || status.State.Running == nil {
+		return "", fmt.Errorf("container %s of pod %s isn't running", container, pod.Name)
+	}
+
+	_, id, _ := strings.Cut(status.ContainerID, "://")
+	if id == "" {
+		return "", fmt.Errorf("container %s of pod %s has no container id", container, pod.Name)
+	}
+	return id, nil
+}
+
+// helperPod returns a privileged pod that runs a script on a node in the
+// host's PID namespace. The pod becomes ready once the script touches
+// readyFile, and the script is sent SIGTERM when the pod is deleted.
+func (i *kubernetesInjector) helperPod(name, node, script string) *v1.Pod {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower("db-chaos-"+name), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+
+	privileged := true
+	gracePeriod := int64(30)
+
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.namespace,
+			Labels:    i.labels,
+		},
+		Spec: v1.PodSpec{
+			NodeName:                      node,
+			HostPID:                       true,
+			RestartPolicy:                 v1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Tolerations:                   []v1.Toleration{{Operator: v1.TolerationOpExists}},
+			Containers: []v1.Container{
+				{
+					Name:            "helper",
+					Image:           i.helperImage,
+					Command:         []string{"sh", "-c", script},
+					SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+					ReadinessProbe: &v1.Probe{
+						ProbeHandler:  v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"cat", readyFile}}},
+						PeriodSeconds: 1,
+					},
+				},
+			},
+		},
+	}
+}
+
+// runHelpers creates helper pods, returning an injection that waits for them
+// to become ready and deletes them on recovery. Helper pods are labelled like
+// any other object db-chaos creates, so they're deleted by Sweep.
+func (i *kubernetesInjector) runHelpers(kind string, helpers []*v1.Pod) (Injection, error) {
+	pods := i.client.CoreV1().Pods(i.namespace)
+
+	var created []string
+	remove := func() (time.Time, error) {
+		for _, name := range created {
+			if err := pods.Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return time.Time{}, fmt.Errorf("deleting helper pod %s: %w", name, err)
+			}
+		}
+
+		// The fault is only undone once the helper pod has been
+		// terminated.
+		for _, name := range created {
+			_, err := waitFor(i.timeout, fmt.Sprintf("helper pod %s to be deleted", name), func() (bool, error) {
+				_, err := pods.Get(context.Background(), name, metav1.GetOptions{})
+				if k8serrors.IsNotFound(err) {
+					return true, nil
+				}
+				return false, err
+			})
+			if err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	for _, helper := range helpers {
+		if _, err := pods.Create(context.Background(), helper, metav1.CreateOptions{}); err != nil {
+			remove()
+			return Injection{}, fmt.Errorf("creating helper pod %s: %w", helper.Name, err)
+		}
+		created = append(created, helper.Name)
+		log.Printf("[%s] started helper pod %s on node %s", yellow("chaos"), helper.Name, helper.Spec.NodeName)
+	}
+
+	return Injection{
+		Kind: kind,
+		Wait: func() (time.Time, error) {
+			for _, name := range created {
+				if err := i.waitForHelper(name); err != nil {
+					return time.Time{}, err
+				}
+			}
+			return time.Now(), nil
+		},
+		Recover: remove,
+	}, nil
+}
+
+// waitForHelper waits for a helper pod to become ready, returning its logs
+// as an error if it fails to inject its fault.
+func (i *kubernetesInjector) waitForHelper(name string) error {
+	pods := i.client.CoreV1().Pods(i.namespace)
+
+	_, err := waitFor(i.timeout, fmt.Sprintf("helper pod %s to be ready", name), func() (bool, error) {
+		pod, err := pods.Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("getting helper pod %s: %w", name, err)
+		}
+
+		if pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded {
+			logs, _ := pods.GetLogs(name, &v1.PodLogOptions{}).DoRaw(context.Background())
+			return false, fmt.Errorf("helper pod %s exited: %s", name, strings.TrimSpace(string(logs)))
+		}
+
+		return lo.ContainsBy(pod.Status.Conditions, func(c v1.PodCondition) bool {
+			return c.Type == v1.PodReady && c.Status == v1.ConditionTrue
+		}), nil
+	})
+	return err
+}
@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// Fault injection backends.
+const (
+	// BackendChaosMesh injects faults by creating Chaos Mesh objects,
+	// falling back to the Kubernetes backend for faults that don't need
+	// Chaos Mesh.
+	BackendChaosMesh = "chaos-mesh"
+
+	// BackendKubernetes injects faults using only the Kubernetes API, for
+	// clusters that don't allow Chaos Mesh to be installed.
+	BackendKubernetes = "kubernetes"
+
+	// BackendToxiproxy injects faults into the workload's connections to
+	// each database node through Toxiproxy, which needs no cluster at all.
+	BackendToxiproxy = "toxiproxy"
+
+	// BackendProxy injects faults into the workload's connections to each
+	// database node through db-chaos's own proxy.
+	BackendProxy = "proxy"
+
+	// BackendLocal injects faults into database nodes running as local
+	// processes or Docker containers, by signalling them and applying
+	// iptables and tc rules in their network namespaces.
+	BackendLocal = "local"
+)
+
+// Injector injects faults into database pods.
+type Injector interface {
+	// Supports returns true if the injector can run experiments of the
+	// given type.
+	Supports(expType string) bool
+
+	// Inject starts a fault. It should return quickly, leaving the wait
+	// for the fault to take effect to the returned Injection.
+	Inject(f Fault) (Injection, error)
+}
+
+// validator is implemented by injectors that can't run every experiment of
+// a type they support, such as those needing extra configuration, so that
+// they're rejected before the run starts.
+type validator interface {
+	Validate(exp scenario.Experiment) error
+}
+
+// Fault describes a single fault to inject.
+type Fault struct {
+	// Name uniquely identifies the fault within the experiment.
+	Name       string
+	Experiment scenario.Experiment
+
+	// Pods are the pods the fault is injected into, and Peers the pods
+	// they're partitioned from for partitions.
+	Pods  []string
+	Peers []string
+
+	// Object is the Chaos Mesh object that injects the fault, if the fault
+	// can be injected by Chaos Mesh.
+	Object chaos.Object
+}
+
+// faulted returns the pods that count towards the limit on pods faulted at
+// once, which for a partition is its smaller side.
+func (f Fault) faulted() []string {
+	if len(f.Peers) > 0 && len(f.Peers) < len(f.Pods) {
+		return f.Peers
+	}
+	return f.Pods
+}
+
+// affected returns every pod affected by the fault.
+func (f Fault) affected() []string {
+	return slices.Concat(f.Pods, f.Peers)
+}
+
+// Injection is a fault that has been started.
+type Injection struct {
+	// Kind describes how the fault was injected (e.g. PodChaos).
+	Kind string
+
+	// Wait blocks until the fault has taken effect and returns the time it
+	// did so.
+	Wait func() (time.Time, error)
+
+	// Recover removes the fault and returns the time it was recovered from.
+	Recover func() (time.Time, error)
+}
+
+// newInjector returns the injector for a backend.
+func (r *ChaosRunner) newInjector() (Injector, error) {
+	native := &kubernetesInjector{
+		client:      r.kubeClient,
+		namespace:   r.opts.Namespace,
+		statefulSet: r.opts.StatefulSet,
+		labels:      r.labels(),
+		timeout:     r.opts.InjectTimeout,
+		helperImage: r.opts.HelperImage,
+	}
+
+	switch r.opts.Backend {
+	case BackendChaosMesh, "":
+		return &chaosMeshInjector{
+			restConfig: r.kubeRestConfig,
+			dyn:        r.kubeClientDyn,
+			labels:     r.labels(),
+			timeout:    r.opts.InjectTimeout,
+			native:     native,
+		}, nil
+
+	case BackendKubernetes:
+		return native, nil
+
+	case BackendToxiproxy:
+		if r.scenario.Proxy == nil {
+			return nil, fmt.Errorf("the %s backend requires the scenario to configure a proxy", BackendToxiproxy)
+		}
+		return &toxiproxyInjector{client: newToxiproxy(r.scenario.Proxy.URL)}, nil
+
+	case BackendProxy:
+		if len(r.opts.Proxies) == 0 {
+			return nil, fmt.Errorf("the %s backend requires proxies to be started", BackendProxy)
+		}
+		return &proxyInjector{proxies: r.opts.Proxies}, nil
+
+	case BackendLocal:
+		if r.scenario.Local == nil {
+			return nil, fmt.Errorf("the %s backend requires the scenario to configure local nodes", BackendLocal)
+		}
+		return newLocalInjector(r.scenario.Local, r.opts.InjectTimeout), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported backend: %q", r.opts.Backend)
+	}
+}
+
+// checkSupported returns an error if any of the scenario's experiments
+// can't be run by the injector.
+func checkSupported(injector Injector, experiments []scenario.Experiment) error {
+	for _, exp := range experiments {
+		if exp.Type == scenario.TypeConcurrent {
+			if err := checkSupported(injector, exp.Experiments); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !injector.Supports(exp.Type) {
+			return fmt.Errorf("%s experiments aren't supported by the %s backend", exp.Type, backendName(injector))
+		}
+
+		if v, ok := injector.(validator); ok {
+			if err := v.Validate(exp); err != nil {
+				return fmt.Errorf("%s: %w", exp.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// requireChaosMesh returns an error if the runner isn't using the Chaos Mesh
+// backend, for features that are implemented by Chaos Mesh.
+func (r *ChaosRunner) requireChaosMesh(feature string) error {
+	if backendName(r.injector) != BackendChaosMesh {
+		return fmt.Errorf("%s requires the %s backend", feature, BackendChaosMesh)
+	}
+	return nil
+}
+
+func backendName(injector Injector) string {
+	switch injector.(type) {
+	case *kubernetesInjector:
+		return BackendKubernetes
+	case *toxiproxyInjector:
+		return BackendToxiproxy
+	case *proxyInjector:
+		return BackendProxy
+	case *localInjector:
+		return BackendLocal
+	default:
+		return BackendChaosMesh
+	}
+}
+
+// clusterless returns true if the backend injects faults without a
+// Kubernetes cluster, either through a proxy in front of each database node
+// or into local nodes.
+func (o ChaosOptions) clusterless() bool {
+	return o.Backend == BackendToxiproxy || o.Backend == BackendProxy || o.Backend == BackendLocal
+}
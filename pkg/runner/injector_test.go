@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+func TestCheckSupported(t *testing.T) {
+	scale := scenario.Experiment{Name: "scale", Type: scenario.TypeScale, Scale: &scenario.Scale{Replicas: 2}}
+
+	cases := []struct {
+		name        string
+		injector    Injector
+		experiments []scenario.Experiment
+		expErr      string
+	}{
+		{
+			name:        "supported",
+			injector:    &kubernetesInjector{},
+			experiments: []scenario.Experiment{{Name: "kill", Type: scenario.TypePodKill}},
+		},
+		{
+			name:        "unsupported type",
+			injector:    &kubernetesInjector{},
+			experiments: []scenario.Experiment{{Name: "delay", Type: scenario.TypeNetworkDelay}},
+			expErr:      "network-delay experiments aren't supported by the kubernetes backend",
+		},
+		{
+			name:     "unsupported type in concurrent group",
+			injector: &kubernetesInjector{},
+			experiments: []scenario.Experiment{{
+				Name: "group",
+				Type: scenario.TypeConcurrent,
+				Experiments: []scenario.Experiment{
+					{Name: "kill", Type: scenario.TypePodKill},
+					{Name: "skew", Type: scenario.TypeClockSkew},
+				},
+			}},
+			expErr: "clock-skew experiments aren't supported by the kubernetes backend",
+		},
+		{
+			name:        "scale with statefulset",
+			injector:    &kubernetesInjector{statefulSet: "cockroachdb"},
+			experiments: []scenario.Experiment{scale},
+		},
+		{
+			name:        "scale without statefulset",
+			injector:    &kubernetesInjector{},
+			experiments: []scenario.Experiment{scale},
+			expErr:      "scale: scale experiments require --statefulset",
+		},
+		{
+			name:        "scale without statefulset on chaos mesh",
+			injector:    &chaosMeshInjector{native: &kubernetesInjector{}},
+			experiments: []scenario.Experiment{scale},
+			expErr:      "scale: scale experiments require --statefulset",
+		},
+		{
+			name:        "proxy timeout without after",
+			injector:    &proxyInjector{},
+			experiments: []scenario.Experiment{{Name: "stall", Type: scenario.TypeNetworkTimeout}},
+		},
+		{
+			name:        "proxy timeout with after",
+			injector:    &proxyInjector{},
+			experiments: []scenario.Experiment{{Name: "stall", Type: scenario.TypeNetworkTimeout, Timeout: &scenario.Timeout{After: time.Second}}},
+			expErr:      "stall: timeout after isn't supported by the proxy backend",
+		},
+		{
+			name:        "proxy peers",
+			injector:    &proxyInjector{},
+			experiments: []scenario.Experiment{{Name: "slow", Type: scenario.TypeNetworkDelay, Peers: []string{"node-2"}}},
+			expErr:      "slow: peers aren't supported by the proxy backend",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkSupported(c.injector, c.experiments)
+			if c.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expErr) {
+					t.Fatalf("expected error containing %q, got %v", c.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
+
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
@@ -17,7 +19,9 @@ import (
 	"k8s.io/client-go/restmapper"
 )
 
-func applyExperiment(kubeRestConfig *rest.Config, dynClient *dynamic.DynamicClient, exp any) (func() error, error) {
+// applyExperiment creates a chaos experiment and returns the client for its
+// resource, through which it can be watched and deleted.
+func applyExperiment(kubeRestConfig *rest.Config, dynClient *dynamic.DynamicClient, exp any, labels map[string]string) (dynamic.ResourceInterface, error) {
 	yamlBytes, err := yamlenc.Marshal(exp)
 	if err != nil {
 		return nil, fmt.Errorf("marshalling object yaml: %w", err)
@@ -33,6 +37,7 @@ func applyExperiment(kubeRestConfig *rest.Config, dynClient *dynamic.DynamicClie
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode YAML: %v", err)
 	}
+	obj.SetLabels(lo.Assign(obj.GetLabels(), labels))
 
 	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
@@ -55,47 +60,5 @@ func applyExperiment(kubeRestConfig *rest.Config, dynClient *dynamic.DynamicClie
 		return nil, fmt.Errorf("failed to create resource: %v", err)
 	}
 
-	delete := func() error {
-		name := obj.GetName()
-		return dr.Delete(context.Background(), name, metav1.DeleteOptions{})
-	}
-
-	return delete, nil
-}
-
-func deleteExperiment(kubeRestConfig *rest.Config, dynClient *dynamic.DynamicClient, exp any) error {
-	yamlBytes, err := yamlenc.Marshal(exp)
-	if err != nil {
-		return fmt.Errorf("marshalling object yaml: %w", err)
-	}
-
-	// Create RESTMapper to discover GVR
-	discoClient := discovery.NewDiscoveryClientForConfigOrDie(kubeRestConfig)
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoClient))
-
-	// Decode YAML to Unstructured
-	decUnstructured := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
-	obj := &unstructured.Unstructured{}
-	_, gvk, err := decUnstructured.Decode(yamlBytes, nil, obj)
-	if err != nil {
-		return fmt.Errorf("failed to decode YAML: %v", err)
-	}
-
-	// Map GVK to GVR
-	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
-	if err != nil {
-		return fmt.Errorf("failed to get REST mapping: %v", err)
-	}
-
-	// Get the resource interface
-	var dr dynamic.ResourceInterface
-	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-		namespace := obj.GetNamespace()
-		dr = dynClient.Resource(mapping.Resource).Namespace(namespace)
-	} else {
-		dr = dynClient.Resource(mapping.Resource)
-	}
-
-	name := obj.GetName()
-	return dr.Delete(context.Background(), name, metav1.DeleteOptions{})
+	return dr, nil
 }
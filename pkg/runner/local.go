@@ -0,0 +1,487 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+)
+
+// netemHandle is the handle of the netem qdiscs added by db-chaos, so that
+// qdiscs left behind by a killed run can be told apart from others.
+const netemHandle = "dbc:"
+
+// localInjector injects faults into database nodes running as local
+// processes or Docker containers, by signalling or pausing them and
+// applying iptables and tc rules in their network namespaces.
+type localInjector struct {
+	nodes   map[string]scenario.LocalNode
+	timeout time.Duration
+}
+
+func newLocalInjector(l *scenario.Local, timeout time.Duration) *localInjector {
+	return &localInjector{
+		nodes:   lo.KeyBy(l.Nodes, func(n scenario.LocalNode) string { return n.Name }),
+		timeout: timeout,
+	}
+}
+
+func (i *localInjector) Supports(expType string) bool {
+	switch expType {
+	case scenario.TypePodKill, scenario.TypePodFailure, scenario.TypePodFreeze, scenario.TypePartition:
+		return true
+	default:
+		return scenario.Experiment{Type: expType}.IsNetworkDegradation()
+	}
+}
+
+func (i *localInjector) Inject(f Fault) (Injection, error) {
+	nodes, err := i.lookup(f.Pods)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	switch exp := f.Experiment; {
+	case exp.Type == scenario.TypePodKill:
+		return i.kill(nodes, true)
+	case exp.Type == scenario.TypePodFailure:
+		return i.kill(nodes, false)
+	case exp.Type == scenario.TypePodFreeze:
+		return i.freeze(nodes)
+	case exp.Type == scenario.TypePartition:
+		return i.partition(f, nodes)
+	case exp.IsNetworkDegradation():
+		return i.degrade(exp, nodes)
+	default:
+		return Injection{}, fmt.Errorf("unsupported experiment type: %q", exp.Type)
+	}
+}
+
+func (i *localInjector) lookup(names []string) ([]scenario.LocalNode, error) {
+	nodes := make([]scenario.LocalNode, len(names))
+	for j, name := range names {
+		n, ok := i.nodes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown node %s", name)
+		}
+		nodes[j] = n
+	}
+	return nodes, nil
+}
+
+// kill sends SIGKILL to each node and starts it again, either as soon as
+// it's stopped (like a pod being restarted) or once the fault is removed
+// (like a pod failing).
+func (i *localInjector) kill(nodes []scenario.LocalNode, restart bool) (Injection, error) {
+	for _, n := range nodes {
+		if n.Container == "" && len(n.Start) == 0 {
+			return Injection{}, fmt.Errorf("node %s has no start command to bring it back with", n.Name)
+		}
+	}
+
+	for _, n := range nodes {
+		if err := signalNode(n, "KILL"); err != nil {
+			return Injection{}, err
+		}
+	}
+
+	start := func() (time.Time, error) {
+		for _, n := range nodes {
+			if err := startNode(n); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	return Injection{
+		Kind: "Signal",
+		Wait: func() (time.Time, error) {
+			for _, n := range nodes {
+				_, err := waitFor(i.timeout, fmt.Sprintf("node %s to stop", n.Name), func() (bool, error) {
+					return !nodeRunning(n), nil
+				})
+				if err != nil {
+					return time.Time{}, err
+				}
+			}
+
+			stopped := time.Now()
+			if restart {
+				if _, err := start(); err != nil {
+					return time.Time{}, err
+				}
+			}
+			return stopped, nil
+		},
+		Recover: func() (time.Time, error) {
+			if restart {
+				return time.Now(), nil
+			}
+			return start()
+		},
+	}, nil
+}
+
+// freeze suspends each node until the fault is removed, leaving its
+// connections open. Containers are paused with docker pause, which freezes
+// every process in the container's cgroup, while local processes are sent
+// SIGSTOP.
+func (i *localInjector) freeze(nodes []scenario.LocalNode) (Injection, error) {
+	for _, n := range nodes {
+		if err := pauseNode(n); err != nil {
+			return Injection{}, err
+		}
+	}
+
+	return Injection{
+		Kind: "Signal",
+		Wait: func() (time.Time, error) { return time.Now(), nil },
+		Recover: func() (time.Time, error) {
+			for _, n := range nodes {
+				if err := unpauseNode(n); err != nil {
+					return time.Time{}, err
+				}
+			}
+			return time.Now(), nil
+		},
+	}, nil
+}
+
+// partition drops traffic between the nodes on the first side of the
+// partition and the peers' IPs, using iptables rules commented with the
+// fault's name so they can be told apart from other rules (see SweepLocal).
+func (i *localInjector) partition(f Fault, nodes []scenario.LocalNode) (Injection, error) {
+	peers, err := i.lookup(f.Peers)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	var ips []string
+	for _, peer := range peers {
+		ip, err := nodeIP(peer)
+		if err != nil {
+			return Injection{}, err
+		}
+		ips = append(ips, ip)
+	}
+
+	var rules [][]string
+	for _, ip := range ips {
+		if f.Experiment.Direction != "from" {
+			rules = append(rules, []string{"OUTPUT", "-d", ip})
+		}
+		if f.Experiment.Direction != "to" {
+			rules = append(rules, []string{"INPUT", "-s", ip})
+		}
+	}
+
+	comment := toxicPrefix + f.Name
+	iptables := func(n scenario.LocalNode, op string, rule []string) error {
+		args := slices.Concat([]string{"iptables", op}, rule, []string{"-m", "comment", "--comment", comment, "-j", "DROP"})
+		return inNetNS(n, args...)
+	}
+
+	type applied struct {
+		node scenario.LocalNode
+		rule []string
+	}
+	var added []applied
+
+	remove := func() (time.Time, error) {
+		for _, a := range added {
+			if err := iptables(a.node, "-D", a.rule); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	for _, n := range nodes {
+		for _, rule := range rules {
+			if err = iptables(n, "-I", rule); err != nil {
+				remove()
+				return Injection{}, err
+			}
+			added = append(added, applied{node: n, rule: rule})
+		}
+	}
+	injected := time.Now()
+
+	return Injection{
+		Kind:    "Iptables",
+		Wait:    func() (time.Time, error) { return injected, nil },
+		Recover: remove,
+	}, nil
+}
+
+// degrade adds a netem qdisc to each node's interface. As netem only
+// shapes outgoing traffic, only the "to" direction is supported, and all
+// traffic is affected.
+func (i *localInjector) degrade(exp scenario.Experiment, nodes []scenario.LocalNode) (Injection, error) {
+	if exp.Direction != "to" {
+		return Injection{}, fmt.Errorf("only direction \"to\" is supported for network degradation by the %s backend", BackendLocal)
+	}
+	if len(exp.Peers) > 0 {
+		return Injection{}, fmt.Errorf("peers aren't supported by the %s backend", BackendLocal)
+	}
+
+	var degraded []scenario.LocalNode
+	remove := func() (time.Time, error) {
+		for _, n := range degraded {
+			if err := inNetNS(n, "tc", "qdisc", "del", "dev", n.Interface, "root"); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	for _, n := range nodes {
+		args := slices.Concat([]string{"tc", "qdisc", "add", "dev", n.Interface, "root", "handle", netemHandle, "netem"}, netemArgs(exp))
+		if err := inNetNS(n, args...); err != nil {
+			remove()
+			return Injection{}, err
+		}
+		degraded = append(degraded, n)
+	}
+	injected := time.Now()
+
+	return Injection{
+		Kind:    "Netem",
+		Wait:    func() (time.Time, error) { return injected, nil },
+		Recover: remove,
+	}, nil
+}
+
+// SweepLocal removes the iptables rules and netem qdiscs left on local
+// nodes by runs that were killed before they could recover from their
+// faults. It must be called before any faults are injected. Nodes without
+// a network namespace to inspect, such as stopped containers, are skipped.
+func SweepLocal(l *scenario.Local) error {
+	if l == nil {
+		return fmt.Errorf("the scenario has no local configuration")
+	}
+
+	for _, n := range l.Nodes {
+		rules, err := outputInNetNS(n, "iptables", "-S")
+		if err != nil {
+			log.Printf("[%s] skipping sweep of node %s: %v", yellow("chaos"), n.Name, err)
+			continue
+		}
+
+		for _, rule := range strings.Split(rules, "\n") {
+			args, ok := sweepableRule(rule)
+			if !ok {
+				continue
+			}
+			if err = inNetNS(n, slices.Concat([]string{"iptables"}, args)...); err != nil {
+				return err
+			}
+			log.Printf("[%s] removed iptables rule left behind by a previous run: %s: %s", yellow("chaos"), n.Name, rule)
+		}
+
+		qdiscs, err := outputInNetNS(n, "tc", "qdisc", "show", "dev", n.Interface)
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(qdiscs, "qdisc netem "+netemHandle+" root") {
+			if err = inNetNS(n, "tc", "qdisc", "del", "dev", n.Interface, "root"); err != nil {
+				return err
+			}
+			log.Printf("[%s] removed netem qdisc left behind by a previous run: %s/%s", yellow("chaos"), n.Name, n.Interface)
+		}
+	}
+
+	return nil
+}
+
+// sweepableRule returns the iptables arguments that delete a rule listed
+// by iptables -S, if the rule was added by db-chaos.
+func sweepableRule(rule string) ([]string, bool) {
+	fields := strings.Fields(rule)
+	if len(fields) == 0 || fields[0] != "-A" {
+		return nil, false
+	}
+
+	i := slices.Index(fields, "--comment")
+	if i < 0 || i+1 >= len(fields) || !strings.HasPrefix(strings.Trim(fields[i+1], `"`), toxicPrefix) {
+		return nil, false
+	}
+
+	fields[0] = "-D"
+	fields[i+1] = strings.Trim(fields[i+1], `"`)
+	return fields, true
+}
+
+// netemArgs returns the netem options that degrade traffic as the
+// experiment describes.
+func netemArgs(exp scenario.Experiment) []string {
+	withCorrelation := func(args []string, correlation float64) []string {
+		if c := percent(correlation); c != "" {
+			return append(args, c+"%")
+		}
+		return args
+	}
+
+	switch exp.Type {
+	case scenario.TypeNetworkDelay:
+		args := []string{"delay", fmt.Sprintf("%dus", exp.Delay.Latency.Microseconds())}
+		if exp.Delay.Jitter > 0 {
+			args = append(args, fmt.Sprintf("%dus", exp.Delay.Jitter.Microseconds()))
+			args = withCorrelation(args, exp.Delay.Correlation)
+		}
+		return args
+
+	case scenario.TypeNetworkLoss:
+		return withCorrelation([]string{"loss", percent(exp.Loss.Percent) + "%"}, exp.Loss.Correlation)
+
+	case scenario.TypeNetworkDuplicate:
+		return withCorrelation([]string{"duplicate", percent(exp.Duplicate.Percent) + "%"}, exp.Duplicate.Correlation)
+
+	case scenario.TypeNetworkCorrupt:
+		return withCorrelation([]string{"corrupt", percent(exp.Corrupt.Percent) + "%"}, exp.Corrupt.Correlation)
+
+	default:
+		return []string{"rate", exp.Bandwidth.Rate}
+	}
+}
+
+// signalNode sends a signal (e.g. KILL) to a node's process.
+func signalNode(n scenario.LocalNode, signal string) error {
+	if n.Container != "" {
+		return run("docker", "kill", "--signal", signal, n.Container)
+	}
+
+	pid, err := readPID(n.PIDFile)
+	if err != nil {
+		return err
+	}
+	return run("kill", "-s", signal, pid)
+}
+
+// pauseNode suspends a node's processes.
+func pauseNode(n scenario.LocalNode) error {
+	if n.Container != "" {
+		return run("docker", "pause", n.Container)
+	}
+	return signalNode(n, "STOP")
+}
+
+// unpauseNode resumes a node suspended by pauseNode.
+func unpauseNode(n scenario.LocalNode) error {
+	if n.Container != "" {
+		return run("docker", "unpause", n.Container)
+	}
+	return signalNode(n, "CONT")
+}
+
+func nodeRunning(n scenario.LocalNode) bool {
+	if n.Container != "" {
+		out, err := output("docker", "inspect", "--format", "{{.State.Running}}", n.Container)
+		return err == nil && out == "true"
+	}
+
+	pid, err := readPID(n.PIDFile)
+	return err == nil && run("kill", "-0", pid) == nil
+}
+
+// startNode starts a stopped node. Local processes are started in the
+// background, detached from db-chaos so that interrupting it doesn't stop
+// them, and are expected to write a new PID file.
+func startNode(n scenario.LocalNode) error {
+	if n.Container != "" {
+		return run("docker", "start", n.Container)
+	}
+
+	cmd := exec.Command(n.Start[0], n.Start[1:]...)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting node %s: %w", n.Name, err)
+	}
+	go cmd.Wait()
+
+	log.Printf("[%s] started node %s (pid %d)", yellow("chaos"), n.Name, cmd.Process.Pid)
+	return nil
+}
+
+func readPID(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading pid file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// nodeIP returns the address other nodes reach a node on.
+func nodeIP(n scenario.LocalNode) (string, error) {
+	if n.IP != "" || n.Container == "" {
+		if n.IP == "" {
+			return "", fmt.Errorf("node %s has no ip", n.Name)
+		}
+		return n.IP, nil
+	}
+
+	out, err := output("docker", "inspect", "--format", "{{range .NetworkSettings.Networks}}{{.IPAddress}} {{end}}", n.Container)
+	if err != nil {
+		return "", err
+	}
+
+	ips := strings.Fields(out)
+	if len(ips) == 0 {
+		return "", fmt.Errorf("container %s has no ip", n.Container)
+	}
+	return ips[0], nil
+}
+
+// inNetNS runs a command in a node's network namespace.
+func inNetNS(n scenario.LocalNode, args ...string) error {
+	_, err := outputInNetNS(n, args...)
+	return err
+}
+
+// outputInNetNS runs a command in a node's network namespace and returns
+// its output.
+func outputInNetNS(n scenario.LocalNode, args ...string) (string, error) {
+	if n.Container != "" {
+		pid, err := output("docker", "inspect", "--format", "{{.State.Pid}}", n.Container)
+		if err != nil {
+			return "", err
+		}
+		if pid == "0" {
+			return "", fmt.Errorf("container %s isn't running", n.Container)
+		}
+		return output(slices.Concat([]string{"nsenter", "--target", pid, "--net"}, args)...)
+	}
+
+	if n.NetNS == "" {
+		return "", fmt.Errorf("node %s has no network namespace to apply network faults in", n.Name)
+	}
+	return output(slices.Concat([]string{"ip", "netns", "exec", n.NetNS}, args)...)
+}
+
+func run(args ...string) error {
+	_, err := output(args...)
+	return err
+}
+
+// output runs a command and returns its trimmed output, including the
+// output in the error if it fails.
+func output(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running %s: %w: %s", strings.Join(args, " "), err, bytes.TrimSpace(out.Bytes()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
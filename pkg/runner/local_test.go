@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSweepableRule(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		exp   []string
+		expOK bool
+	}{
+		{
+			name:  "db-chaos rule",
+			rule:  "-A INPUT -s 10.0.0.2/32 -m comment --comment db-chaos-partition -j DROP",
+			exp:   []string{"-D", "INPUT", "-s", "10.0.0.2/32", "-m", "comment", "--comment", "db-chaos-partition", "-j", "DROP"},
+			expOK: true,
+		},
+		{
+			name:  "quoted comment",
+			rule:  `-A OUTPUT -d 10.0.0.3/32 -m comment --comment "db-chaos-split" -j DROP`,
+			exp:   []string{"-D", "OUTPUT", "-d", "10.0.0.3/32", "-m", "comment", "--comment", "db-chaos-split", "-j", "DROP"},
+			expOK: true,
+		},
+		{name: "other comment", rule: "-A INPUT -s 10.0.0.2/32 -m comment --comment mine -j DROP"},
+		{name: "no comment", rule: "-A INPUT -s 10.0.0.2/32 -j DROP"},
+		{name: "policy", rule: "-P INPUT ACCEPT"},
+		{name: "empty", rule: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act, ok := sweepableRule(c.rule)
+			if ok != c.expOK {
+				t.Fatalf("exp ok %v, got %v", c.expOK, ok)
+			}
+			if !slices.Equal(act, c.exp) {
+				t.Fatalf("exp %v, got %v", c.exp, act)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts a command in its own session, so that it isn't sent the
+// signals (such as a Ctrl-C) meant for db-chaos and outlives it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,524 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// podNameLabel is set by the StatefulSet controller on every pod it
+	// creates, which allows NetworkPolicies to select individual pods.
+	podNameLabel = "statefulset.kubernetes.io/pod-name"
+
+	// replicasAnnotation records the number of replicas a StatefulSet had
+	// before it was scaled down, so that Sweep can restore it.
+	replicasAnnotation = "db-chaos/replicas"
+)
+
+// noObject builds no Chaos Mesh object, for experiments that Chaos Mesh has
+// no equivalent of, which are injected by the backend itself.
+func noObject(string, []string) (chaos.Object, error) {
+	return nil, nil
+}
+
+// Scale scales the database's StatefulSet down for the duration of the
+// experiment, removing the pods with the highest ordinals.
+func (r *ChaosRunner) Scale(exp scenario.Experiment) error {
+	removed := lo.Filter(r.pods, func(pod string, _ int) bool {
+		ordinal, ok := podOrdinal(pod)
+		return ok && ordinal >= exp.Scale.Replicas
+	})
+
+	log.Printf("[%s] scaling %s down to %d replicas, removing %v", yellow("chaos"), r.opts.StatefulSet, exp.Scale.Replicas, removed)
+	return r.inject(Fault{Name: exp.Name, Experiment: exp, Pods: removed})
+}
+
+// podOrdinal returns the ordinal of a pod created by a StatefulSet.
+func podOrdinal(pod string) (int, bool) {
+	i := strings.LastIndex(pod, "-")
+	if i == -1 {
+		return 0, false
+	}
+
+	ordinal, err := strconv.Atoi(pod[i+1:])
+	return ordinal, err == nil
+}
+
+// kubernetesInjector injects faults using only the Kubernetes API.
+type kubernetesInjector struct {
+	client      *kubernetes.Clientset
+	namespace   string
+	statefulSet string
+	labels      map[string]string
+	timeout     time.Duration
+	helperImage string
+}
+
+func (i *kubernetesInjector) Supports(expType string) bool {
+	switch expType {
+	case scenario.TypePodKill, scenario.TypePodEvict, scenario.TypePartition, scenario.TypeScale, scenario.TypeNodeDrain, scenario.TypePodFreeze, scenario.TypeNodeCordon, scenario.TypeKubeletStop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (i *kubernetesInjector) Validate(exp scenario.Experiment) error {
+	if exp.Type == scenario.TypeScale && i.statefulSet == "" {
+		return fmt.Errorf("scale experiments require --statefulset")
+	}
+	return nil
+}
+
+func (i *kubernetesInjector) Inject(f Fault) (Injection, error) {
+	switch f.Experiment.Type {
+	case scenario.TypePodKill:
+		return i.killPods(f.Pods)
+	case scenario.TypePodEvict:
+		return i.evictPods(f.Pods)
+	case scenario.TypePartition:
+		return i.partition(f)
+	case scenario.TypeScale:
+		return i.scale(f.Experiment.Scale.Replicas)
+	case scenario.TypeNodeDrain, scenario.TypeNodeCordon:
+		return i.drainNodes(f.Pods, f.Experiment)
+	case scenario.TypeKubeletStop:
+		return i.stopKubelets(f)
+	case scenario.TypePodFreeze:
+		return i.freezePods(f)
+	default:
+		return Injection{}, fmt.Errorf("unsupported experiment type: %q", f.Experiment.Type)
+	}
+}
+
+// killPods deletes pods without a grace period. There's nothing to recover,
+// as the pods' controller replaces them.
+func (i *kubernetesInjector) killPods(pods []string) (Injection, error) {
+	gracePeriod := int64(0)
+
+	for _, pod := range pods {
+		err := i.client.CoreV1().Pods(i.namespace).Delete(context.Background(), pod, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		if err != nil {
+			return Injection{}, fmt.Errorf("deleting pod %s: %w", pod, err)
+		}
+	}
+
+	return immediate("Pod"), nil
+}
+
+// evictPods evicts pods using the eviction API, which respects any
+// PodDisruptionBudgets covering them.
+func (i *kubernetesInjector) evictPods(pods []string) (Injection, error) {
+	for _, pod := range pods {
+		if err := i.evict(i.namespace, pod); err != nil {
+			return Injection{}, err
+		}
+	}
+
+	return immediate("Eviction"), nil
+}
+
+func (i *kubernetesInjector) evict(namespace, pod string) error {
+	eviction := policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod,
+			Namespace: namespace,
+		},
+	}
+
+	err := i.client.PolicyV1().Evictions(namespace).Evict(context.Background(), &eviction)
+	switch {
+	case k8serrors.IsTooManyRequests(err):
+		return fmt.Errorf("eviction of pod %s blocked by a PodDisruptionBudget: %w", pod, err)
+	case k8serrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("evicting pod %s: %w", pod, err)
+	default:
+		return nil
+	}
+}
+
+// partition applies a NetworkPolicy to one side of the partition that
+// allows traffic from and to everything except the other side. Pods on the
+// other side are excluded both by label and by IP, as CNI plugins differ
+// in whether ipBlocks apply to pod traffic.
+func (i *kubernetesInjector) partition(f Fault) (Injection, error) {
+	var except []string
+	for _, pod := range f.Peers {
+		p, err := i.client.CoreV1().Pods(i.namespace).Get(context.Background(), pod, metav1.GetOptions{})
+		if err != nil {
+			return Injection{}, fmt.Errorf("getting pod %s: %w", pod, err)
+		}
+
+		for _, ip := range p.Status.PodIPs {
+			if strings.Contains(ip.IP, ":") {
+				except = append(except, ip.IP+"/128")
+			} else {
+				except = append(except, ip.IP+"/32")
+			}
+		}
+	}
+
+	peers := []networkingv1.NetworkPolicyPeer{
+		{
+			PodSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: podNameLabel, Operator: metav1.LabelSelectorOpNotIn, Values: f.Peers},
+				},
+			},
+		},
+		{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "kubernetes.io/metadata.name", Operator: metav1.LabelSelectorOpNotIn, Values: []string{i.namespace}},
+				},
+			},
+		},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: lo.Filter(except, isIPv4CIDR)}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "::/0", Except: lo.Reject(except, isIPv4CIDR)}},
+	}
+
+	policy := networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      invalidNameChars.ReplaceAllString(strings.ToLower(f.Name), "-"),
+			Namespace: i.namespace,
+			Labels:    i.labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: podNameLabel, Operator: metav1.LabelSelectorOpIn, Values: f.Pods},
+				},
+			},
+		},
+	}
+
+	// NetworkPolicies are stateful, so a partition in one direction only
+	// blocks connections opened in that direction.
+	direction := f.Experiment.Direction
+	if direction == "" || direction == "both" || direction == "from" {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeIngress)
+		policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+	}
+	if direction == "" || direction == "both" || direction == "to" {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		policy.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{{To: peers}}
+	}
+
+	policies := i.client.NetworkingV1().NetworkPolicies(i.namespace)
+	if _, err := policies.Create(context.Background(), &policy, metav1.CreateOptions{}); err != nil {
+		return Injection{}, fmt.Errorf("creating network policy: %w", err)
+	}
+
+	// NetworkPolicies don't report when they're enforced, so the partition
+	// is assumed to be in place as soon as the policy exists.
+	injected := time.Now()
+
+	return Injection{
+		Kind: "NetworkPolicy",
+		Wait: func() (time.Time, error) { return injected, nil },
+		Recover: func() (time.Time, error) {
+			if err := policies.Delete(context.Background(), policy.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return time.Time{}, fmt.Errorf("deleting network policy: %w", err)
+			}
+			return time.Now(), nil
+		},
+	}, nil
+}
+
+func isIPv4CIDR(cidr string, _ int) bool {
+	return !strings.Contains(cidr, ":")
+}
+
+// scale scales the database's StatefulSet down, recording its original size
+// on the StatefulSet so that it can be restored by Sweep if the run is
+// interrupted.
+func (i *kubernetesInjector) scale(replicas int) (Injection, error) {
+	statefulSets := i.client.AppsV1().StatefulSets(i.namespace)
+
+	current, err := statefulSets.GetScale(context.Background(), i.statefulSet, metav1.GetOptions{})
+	if err != nil {
+		return Injection{}, fmt.Errorf("getting scale: %w", err)
+	}
+	original := current.Spec.Replicas
+
+	err = mergePatch(statefulSets.Patch, i.statefulSet, map[string]any{
+		"metadata": map[string]any{
+			"labels":      map[string]any{runIDLabel: i.labels[runIDLabel]},
+			"annotations": map[string]any{replicasAnnotation: strconv.Itoa(int(original))},
+		},
+	})
+	if err != nil {
+		return Injection{}, fmt.Errorf("annotating statefulset: %w", err)
+	}
+
+	if err = setScale(i.client, i.namespace, i.statefulSet, int32(replicas)); err != nil {
+		return Injection{}, err
+	}
+
+	return Injection{
+		Kind: "Scale",
+		Wait: func() (time.Time, error) {
+			return waitFor(i.timeout, fmt.Sprintf("statefulset %s to scale down to %d replicas", i.statefulSet, replicas), func() (bool, error) {
+				sts, err := statefulSets.Get(context.Background(), i.statefulSet, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				return sts.Status.Replicas <= int32(replicas), nil
+			})
+		},
+		Recover: func() (time.Time, error) {
+			if err := setScale(i.client, i.namespace, i.statefulSet, original); err != nil {
+				return time.Time{}, err
+			}
+			return time.Now(), unmarkStatefulSet(i.client, i.namespace, i.statefulSet)
+		},
+	}, nil
+}
+
+// setScale sets the number of replicas of a StatefulSet.
+func setScale(client *kubernetes.Clientset, namespace, statefulSet string, replicas int32) error {
+	scale := autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statefulSet,
+			Namespace: namespace,
+		},
+		Spec: autoscalingv1.ScaleSpec{Replicas: replicas},
+	}
+
+	if _, err := client.AppsV1().StatefulSets(namespace).UpdateScale(context.Background(), statefulSet, &scale, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("scaling statefulset %s to %d: %w", statefulSet, replicas, err)
+	}
+
+	return nil
+}
+
+func unmarkStatefulSet(client *kubernetes.Clientset, namespace, statefulSet string) error {
+	err := mergePatch(client.AppsV1().StatefulSets(namespace).Patch, statefulSet, map[string]any{
+		"metadata": map[string]any{
+			"labels":      map[string]any{runIDLabel: nil},
+			"annotations": map[string]any{replicasAnnotation: nil},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("removing annotations from statefulset %s: %w", statefulSet, err)
+	}
+
+	return nil
+}
+
+// drainNodes cordons the nodes the given pods are scheduled on and, for
+// node-drain experiments, evicts every pod from them that isn't managed by a
+// DaemonSet, as kubectl drain would. Nodes that were already cordoned are
+// left cordoned on recovery.
+func (i *kubernetesInjector) drainNodes(pods []string, exp scenario.Experiment) (Injection, error) {
+	nodes, err := i.nodesOf(pods)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	var cordoned []string
+	uncordonAll := func() (time.Time, error) {
+		for _, node := range cordoned {
+			if err := uncordon(i.client, node); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	for _, node := range nodes {
+		n, err := i.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{})
+		if err != nil {
+			uncordonAll()
+			return Injection{}, fmt.Errorf("getting node %s: %w", node, err)
+		}
+		if n.Spec.Unschedulable {
+			continue
+		}
+
+		err = mergePatch(i.client.CoreV1().Nodes().Patch, node, map[string]any{
+			"metadata": map[string]any{"labels": map[string]any{runIDLabel: i.labels[runIDLabel]}},
+			"spec":     map[string]any{"unschedulable": true},
+		})
+		if err != nil {
+			uncordonAll()
+			return Injection{}, fmt.Errorf("cordoning node %s: %w", node, err)
+		}
+		cordoned = append(cordoned, node)
+		log.Printf("[%s] cordoned node: %s", yellow("chaos"), node)
+	}
+
+	if exp.Type == scenario.TypeNodeCordon {
+		injection := immediate("Cordon")
+		injection.Recover = uncordonAll
+		return injection, nil
+	}
+
+	force := exp.Drain != nil && exp.Drain.Force
+
+	return Injection{
+		Kind: "Drain",
+		Wait: func() (time.Time, error) {
+			for _, node := range nodes {
+				if err := i.drain(node, force); err != nil {
+					return time.Time{}, err
+				}
+			}
+			return time.Now(), nil
+		},
+		Recover: uncordonAll,
+	}, nil
+}
+
+// nodesOf returns the nodes the given pods are scheduled on.
+func (i *kubernetesInjector) nodesOf(pods []string) ([]string, error) {
+	var nodes []string
+	for _, pod := range pods {
+		p, err := i.client.CoreV1().Pods(i.namespace).Get(context.Background(), pod, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting pod %s: %w", pod, err)
+		}
+		if p.Spec.NodeName == "" {
+			return nil, fmt.Errorf("pod %s hasn't been scheduled", pod)
+		}
+		nodes = append(nodes, p.Spec.NodeName)
+	}
+
+	return lo.Uniq(nodes), nil
+}
+
+// drain evicts the pods on a node, retrying evictions blocked by a
+// PodDisruptionBudget until the timeout. If force is set, pods are deleted
+// instead, bypassing PodDisruptionBudgets.
+func (i *kubernetesInjector) drain(node string, force bool) error {
+	list, err := i.client.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node,
+	})
+	if err != nil {
+		return fmt.Errorf("listing pods on node %s: %w", node, err)
+	}
+
+	for _, pod := range list.Items {
+		if !drainable(pod) {
+			continue
+		}
+
+		if force {
+			err = i.client.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("draining node %s: deleting pod %s: %w", node, pod.Name, err)
+			}
+			continue
+		}
+
+		_, err = waitFor(i.timeout, fmt.Sprintf("pod %s to be evicted", pod.Name), func() (bool, error) {
+			err := i.evict(pod.Namespace, pod.Name)
+			if err != nil && k8serrors.IsTooManyRequests(err) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err != nil {
+			return fmt.Errorf("draining node %s: %w", node, err)
+		}
+	}
+
+	log.Printf("[%s] drained node: %s", yellow("chaos"), node)
+	return nil
+}
+
+// drainable returns false for pods that kubectl drain would leave in place.
+func drainable(pod v1.Pod) bool {
+	if _, mirror := pod.Annotations[v1.MirrorPodAnnotationKey]; mirror {
+		return false
+	}
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false
+	}
+
+	return !slices.ContainsFunc(pod.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "DaemonSet"
+	})
+}
+
+func uncordon(client *kubernetes.Clientset, node string) error {
+	err := mergePatch(client.CoreV1().Nodes().Patch, node, map[string]any{
+		"metadata": map[string]any{"labels": map[string]any{runIDLabel: nil}},
+		"spec":     map[string]any{"unschedulable": nil},
+	})
+	if err != nil {
+		return fmt.Errorf("uncordoning node %s: %w", node, err)
+	}
+
+	log.Printf("[%s] uncordoned node: %s", yellow("chaos"), node)
+	return nil
+}
+
+// patchFunc is the Patch method of a typed Kubernetes client.
+type patchFunc[T any] func(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+
+// mergePatch applies a JSON merge patch to an object, where nil values
+// remove fields.
+func mergePatch[T any](fn patchFunc[T], name string, patch map[string]any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("marshalling patch: %w", err)
+	}
+
+	_, err = fn(context.Background(), name, types.MergePatchType, data, metav1.PatchOptions{})
+	return err
+}
+
+// immediate returns an injection that takes effect as soon as it's made
+// and has nothing to recover.
+func immediate(kind string) Injection {
+	injected := time.Now()
+
+	return Injection{
+		Kind:    kind,
+		Wait:    func() (time.Time, error) { return injected, nil },
+		Recover: func() (time.Time, error) { return time.Now(), nil },
+	}
+}
+
+// waitFor polls until done returns true and returns the time it did so.
+func waitFor(wait time.Duration, desc string, done func() (bool, error)) (time.Time, error) {
+	timeout := time.After(wait)
+	check := time.Tick(time.Second)
+
+	for {
+		ok, err := done()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ok {
+			return time.Now(), nil
+		}
+
+		select {
+		case <-check:
+		case <-timeout:
+			return time.Time{}, fmt.Errorf("timed out after %s waiting for %s", wait, desc)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// NemesisStep is a single fault in a randomly generated schedule.
+type NemesisStep struct {
+	// Experiment is a copy of the experiment the fault was picked from,
+	// renamed and given a random duration (and clock offset for clock skew
+	// experiments).
+	Experiment scenario.Experiment
+
+	// Targets are the pods the fault is injected into, and Peers the pods
+	// they're partitioned from for partitions.
+	Targets []string
+	Peers   []string
+
+	// Pause is the time to wait after the fault before the next one.
+	Pause time.Duration
+}
+
+func (s NemesisStep) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s %v", s.Experiment.Name, s.Experiment.Type, s.Targets)
+
+	if len(s.Peers) > 0 {
+		fmt.Fprintf(&b, " from %v", s.Peers)
+	}
+	if s.Experiment.Clock != nil {
+		fmt.Fprintf(&b, " by %s", formatOffset(s.Experiment.Clock.Offsets[0]))
+	}
+
+	fmt.Fprintf(&b, " for %s, then pause %s", s.Experiment.Duration, s.Pause)
+	return b.String()
+}
+
+// Schedule returns the nemesis schedule generated for the run, if any.
+func (r *ChaosRunner) Schedule() []NemesisStep {
+	return r.schedule
+}
+
+// runNemesis generates a random schedule of faults from the scenario's
+// experiments and runs it. The schedule only depends on the seed, scenario
+// and pods, so a run can be replayed by passing the same seed.
+func (r *ChaosRunner) runNemesis() error {
+	schedule, err := nemesisSchedule(r.pods, r.scenario, r.rng)
+	if err != nil {
+		return fmt.Errorf("generating nemesis schedule: %w", err)
+	}
+	r.schedule = schedule
+
+	log.Printf("[%s] nemesis schedule (seed %d):", yellow("chaos"), r.seed)
+	for _, step := range schedule {
+		log.Printf("[%s] \t%s", yellow("chaos"), step)
+	}
+
+	for i, step := range schedule {
+		log.Printf("[%s] Running %s (%d/%d)", yellow("chaos"), step.Experiment.Name, i+1, len(schedule))
+
+		if err = r.runStep(step); err != nil {
+			return fmt.Errorf("running %s: %w", step.Experiment.Name, err)
+		}
+
+		if step.Pause > 0 {
+			r.pause(step.Pause)
+		}
+	}
+
+	return nil
+}
+
+func (r *ChaosRunner) runStep(step NemesisStep) error {
+	if step.Experiment.Type == scenario.TypePartition {
+		return r.during(step.Experiment.Name, func() error {
+			log.Printf("[%s] partitioning %v from %v", yellow("chaos"), step.Targets, step.Peers)
+			return r.partition(step.Experiment, step.Experiment.Name, step.Targets, step.Peers)
+		})
+	}
+
+	return r.runExperiment(step.Targets, step.Experiment)
+}
+
+// nemesisSchedule picks random experiments, targets, durations and pauses
+// until the nemesis duration has been filled.
+func nemesisSchedule(pods []string, s scenario.Scenario, rng *rand.Rand) ([]NemesisStep, error) {
+	n := s.Nemesis
+
+	var schedule []NemesisStep
+	for elapsed := time.Duration(0); ; {
+		step := NemesisStep{}
+		step.Experiment = s.Experiments[rng.IntN(len(s.Experiments))]
+		step.Experiment.Name = fmt.Sprintf("%s-%d", step.Experiment.Name, len(schedule)+1)
+		step.Experiment.Duration = randomDuration(rng, n.MinDuration, n.MaxDuration)
+		step.Experiment.Repeat = 1
+		step.Experiment.Pause = 0
+		step.Pause = randomDuration(rng, n.MinPause, n.MaxPause)
+
+		if elapsed+step.Experiment.Duration > n.Duration {
+			break
+		}
+		elapsed += step.Experiment.Duration + step.Pause
+
+		targets, err := selectTargets(pods, step.Experiment.Targets)
+		if err != nil {
+			return nil, fmt.Errorf("selecting targets for %s: %w", step.Experiment.Name, err)
+		}
+
+		switch {
+		case step.Experiment.Type == scenario.TypePartition:
+			parts, err := partitions(targets, "pods", step.Experiment.Topology, rng)
+			if err != nil {
+				return nil, fmt.Errorf("partitioning %s: %w", step.Experiment.Name, err)
+			}
+			part := parts[rng.IntN(len(parts))]
+			step.Targets, step.Peers = part.a, part.b
+
+		case step.Experiment.Mode == scenario.ModeOne:
+			step.Targets = []string{targets[rng.IntN(len(targets))]}
+
+		default:
+			step.Targets = targets
+		}
+		step.Experiment.Mode = scenario.ModeAll
+
+		if clock := step.Experiment.Clock; clock != nil {
+			step.Experiment.Clock = &scenario.Clock{
+				Offsets:  []time.Duration{clock.Offsets[rng.IntN(len(clock.Offsets))]},
+				ClockIDs: clock.ClockIDs,
+			}
+		}
+
+		schedule = append(schedule, step)
+	}
+
+	// Faults longer than the nemesis duration may be drawn first, which
+	// would otherwise leave nothing to run.
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("no faults fit in the nemesis duration of %s, try lowering maxDuration", n.Duration)
+	}
+
+	return schedule, nil
+}
+
+// randomDuration returns a random duration between lo and hi inclusive,
+// rounded to the second where the range allows.
+func randomDuration(rng *rand.Rand, lo, hi time.Duration) time.Duration {
+	if hi-lo < time.Second {
+		return lo
+	}
+
+	return lo + time.Duration(rng.Int64N(int64((hi-lo)/time.Second)+1))*time.Second
+}
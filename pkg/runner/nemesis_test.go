@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+func TestNemesisSchedule(t *testing.T) {
+	pods := []string{"db-0", "db-1", "db-2"}
+
+	s := scenario.Scenario{
+		Experiments: []scenario.Experiment{
+			{Name: "kill", Type: scenario.TypePodKill, Mode: scenario.ModeOne},
+			{Name: "fail-all", Type: scenario.TypePodFailure, Mode: scenario.ModeAll},
+			{Name: "partition", Type: scenario.TypePartition, Mode: scenario.ModeOne, Direction: "both", Topology: scenario.TopologyIsolate},
+			{Name: "skew", Type: scenario.TypeClockSkew, Mode: scenario.ModeOne, Clock: &scenario.Clock{
+				Offsets: []time.Duration{-time.Second, time.Second},
+			}},
+		},
+		Nemesis: &scenario.Nemesis{
+			Duration:    10 * time.Minute,
+			MinDuration: 10 * time.Second,
+			MaxDuration: time.Minute,
+			MaxPause:    30 * time.Second,
+		},
+	}
+
+	cases := []struct {
+		name     string
+		seedA    uint64
+		seedB    uint64
+		expEqual bool
+	}{
+		{name: "same seed", seedA: 1, seedB: 1, expEqual: true},
+		{name: "another same seed", seedA: 42, seedB: 42, expEqual: true},
+		{name: "different seed", seedA: 1, seedB: 2, expEqual: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, err := nemesisSchedule(pods, s, rand.New(rand.NewPCG(c.seedA, 0)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			b, err := nemesisSchedule(pods, s, rand.New(rand.NewPCG(c.seedB, 0)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if equal := reflect.DeepEqual(a, b); equal != c.expEqual {
+				t.Fatalf("expected schedules to be equal: %t, got:\n%v\n%v", c.expEqual, a, b)
+			}
+
+			var elapsed time.Duration
+			for _, step := range a {
+				elapsed += step.Experiment.Duration
+
+				if d := step.Experiment.Duration; d < s.Nemesis.MinDuration || d > s.Nemesis.MaxDuration {
+					t.Fatalf("%s: duration %s outside of bounds", step.Experiment.Name, d)
+				}
+				if step.Pause < s.Nemesis.MinPause || step.Pause > s.Nemesis.MaxPause {
+					t.Fatalf("%s: pause %s outside of bounds", step.Experiment.Name, step.Pause)
+				}
+				if step.Experiment.Mode != scenario.ModeAll || len(step.Targets) == 0 {
+					t.Fatalf("%s: expected resolved targets, got mode %q and targets %v", step.Experiment.Name, step.Experiment.Mode, step.Targets)
+				}
+				if step.Experiment.Type == scenario.TypeClockSkew && len(step.Experiment.Clock.Offsets) != 1 {
+					t.Fatalf("%s: expected a single clock offset, got %v", step.Experiment.Name, step.Experiment.Clock.Offsets)
+				}
+
+				elapsed += step.Pause
+			}
+
+			if elapsed-a[len(a)-1].Pause > s.Nemesis.Duration {
+				t.Fatalf("expected faults to fit in %s, took %s", s.Nemesis.Duration, elapsed)
+			}
+		})
+	}
+}
+
+func TestNemesisScheduleEmpty(t *testing.T) {
+	s := scenario.Scenario{
+		Experiments: []scenario.Experiment{
+			{Name: "kill", Type: scenario.TypePodKill, Mode: scenario.ModeOne},
+		},
+		Nemesis: &scenario.Nemesis{
+			Duration:    10 * time.Second,
+			MinDuration: time.Minute,
+			MaxDuration: time.Minute,
+		},
+	}
+
+	_, err := nemesisSchedule([]string{"db-0"}, s, rand.New(rand.NewPCG(1, 0)))
+	if err == nil || !strings.Contains(err.Error(), "no faults fit in the nemesis duration") {
+		t.Fatalf("expected empty schedule error, got %v", err)
+	}
+}
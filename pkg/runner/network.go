@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+)
+
+// NetworkDegradation slows or damages the network traffic of the target
+// pods, either to all destinations or just to the experiment's peers.
+func (r *ChaosRunner) NetworkDegradation(pods []string, exp scenario.Experiment) error {
+	return r.eachTarget(pods, exp, func(name string, pods []string) (chaos.Object, error) {
+		peers, err := r.peers(pods, exp)
+		if err != nil {
+			return nil, err
+		}
+
+		chaos := makeNetworkDegradation(name, pods, peers, r.opts.Namespace, r.opts.ChaosNamespace, exp)
+		return &chaos, nil
+	})
+}
+
+// peers returns the pods whose traffic with the given pods should be
+// affected. If no peers have been configured, all traffic is affected where
+// the direction allows it, otherwise all other pods are treated as peers.
+func (r *ChaosRunner) peers(pods []string, exp scenario.Experiment) ([]string, error) {
+	if len(exp.Peers) > 0 {
+		peers, err := selectTargets(r.pods, exp.Peers)
+		if err != nil {
+			return nil, fmt.Errorf("selecting peers: %w", err)
+		}
+		return peers, nil
+	}
+
+	// Chaos Mesh requires a target for traffic in any direction other than
+	// "to".
+	if exp.Direction == "to" {
+		return nil, nil
+	}
+
+	return lo.Without(r.pods, pods...), nil
+}
+
+func makeNetworkDegradation(name string, pods, peers []string, podNS, chaosNS string, exp scenario.Experiment) chaos.NetworkChaos {
+	switch exp.Type {
+	case scenario.TypeNetworkDelay:
+		delay := chaos.Delay{
+			Latency:     exp.Delay.Latency.String(),
+			Correlation: percent(exp.Delay.Correlation),
+		}
+		if exp.Delay.Jitter > 0 {
+			delay.Jitter = exp.Delay.Jitter.String()
+		}
+		return chaos.MakeNetworkDelay(name, pods, peers, podNS, chaosNS, exp.Direction, delay)
+
+	case scenario.TypeNetworkLoss:
+		loss := chaos.Loss{
+			Loss:        percent(exp.Loss.Percent),
+			Correlation: percent(exp.Loss.Correlation),
+		}
+		return chaos.MakeNetworkLoss(name, pods, peers, podNS, chaosNS, exp.Direction, loss)
+
+	case scenario.TypeNetworkDuplicate:
+		duplicate := chaos.Duplicate{
+			Duplicate:   percent(exp.Duplicate.Percent),
+			Correlation: percent(exp.Duplicate.Correlation),
+		}
+		return chaos.MakeNetworkDuplicate(name, pods, peers, podNS, chaosNS, exp.Direction, duplicate)
+
+	case scenario.TypeNetworkCorrupt:
+		corrupt := chaos.Corrupt{
+			Corrupt:     percent(exp.Corrupt.Percent),
+			Correlation: percent(exp.Corrupt.Correlation),
+		}
+		return chaos.MakeNetworkCorrupt(name, pods, peers, podNS, chaosNS, exp.Direction, corrupt)
+
+	default:
+		bandwidth := chaos.Bandwidth{
+			Rate:   exp.Bandwidth.Rate,
+			Limit:  exp.Bandwidth.Limit,
+			Buffer: exp.Bandwidth.Buffer,
+		}
+		return chaos.MakeNetworkBandwidth(name, pods, peers, podNS, chaosNS, exp.Direction, bandwidth)
+	}
+}
+
+// percent formats a percentage in the way Chaos Mesh expects, omitting it
+// entirely if it's zero.
+func percent(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/samber/lo"
+)
+
+// partition separates two groups of pods.
+type partition struct {
+	name string
+	a    []string
+	b    []string
+}
+
+// partitions returns the partitions to run, one at a time, for a topology.
+// The pods may also be failure domains, so errors refer to them by noun.
+func partitions(pods []string, noun, topology string, rng *rand.Rand) ([]partition, error) {
+	if len(pods) < 2 {
+		return nil, fmt.Errorf("at least 2 %s are required to partition, got %d", noun, len(pods))
+	}
+
+	switch topology {
+	case scenario.TopologyPairs:
+		return lo.Map(getPairCombinationsSlices(pods), func(pair [2]string, _ int) partition {
+			return partition{
+				name: fmt.Sprintf("%s-%s", pair[0], pair[1]),
+				a:    pair[:1],
+				b:    pair[1:],
+			}
+		}), nil
+
+	case scenario.TopologyIsolate:
+		return lo.Map(pods, func(pod string, _ int) partition {
+			return partition{
+				name: fmt.Sprintf("%s-isolated", pod),
+				a:    []string{pod},
+				b:    lo.Without(pods, pod),
+			}
+		}), nil
+
+	case scenario.TopologyMajorityMinority:
+		// The largest minority that still leaves a majority.
+		size := (len(pods) - 1) / 2
+		if size == 0 {
+			return nil, fmt.Errorf("at least 3 %s are required for a majority/minority partition, got %d", noun, len(pods))
+		}
+
+		var parts []partition
+		for i := range pods {
+			minority := rotate(pods, i)[:size]
+			parts = append(parts, partition{
+				name: fmt.Sprintf("minority-%s", minority[0]),
+				a:    minority,
+				b:    lo.Without(pods, minority...),
+			})
+		}
+		return parts, nil
+
+	case scenario.TopologyBridge:
+		if len(pods) < 3 {
+			return nil, fmt.Errorf("at least 3 %s are required for a bridge partition, got %d", noun, len(pods))
+		}
+
+		return lo.Map(pods, func(bridge string, _ int) partition {
+			others := lo.Without(pods, bridge)
+			half := len(others) / 2
+			return partition{
+				name: fmt.Sprintf("%s-bridge", bridge),
+				a:    others[:half],
+				b:    others[half:],
+			}
+		}), nil
+
+	case scenario.TopologyRandomHalves:
+		shuffled := slices.Clone(pods)
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		half := len(shuffled) / 2
+		return []partition{{
+			name: "random-halves",
+			a:    shuffled[:half],
+			b:    shuffled[half:],
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported topology: %q", topology)
+	}
+}
+
+func getPairCombinationsSlices(strings []string) [][2]string {
+	var pairs [][2]string
+
+	for i := range strings {
+		for j := range strings {
+			if i != j {
+				pairs = append(pairs, [2]string{strings[i], strings[j]})
+			}
+		}
+	}
+
+	return pairs
+}
+
+// rotate returns a copy of s, rotated to start at index i.
+func rotate(s []string, i int) []string {
+	return append(slices.Clone(s[i:]), s[:i]...)
+}
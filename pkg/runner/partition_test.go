@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+func TestPartitions(t *testing.T) {
+	cases := []struct {
+		name     string
+		pods     []string
+		noun     string
+		topology string
+		exp      []partition
+		expErr   string
+	}{
+		{
+			name:     "pairs",
+			pods:     []string{"a", "b", "c"},
+			topology: scenario.TopologyPairs,
+			exp: []partition{
+				{name: "a-b", a: []string{"a"}, b: []string{"b"}},
+				{name: "a-c", a: []string{"a"}, b: []string{"c"}},
+				{name: "b-a", a: []string{"b"}, b: []string{"a"}},
+				{name: "b-c", a: []string{"b"}, b: []string{"c"}},
+				{name: "c-a", a: []string{"c"}, b: []string{"a"}},
+				{name: "c-b", a: []string{"c"}, b: []string{"b"}},
+			},
+		},
+		{
+			name:     "isolate",
+			pods:     []string{"a", "b", "c"},
+			topology: scenario.TopologyIsolate,
+			exp: []partition{
+				{name: "a-isolated", a: []string{"a"}, b: []string{"b", "c"}},
+				{name: "b-isolated", a: []string{"b"}, b: []string{"a", "c"}},
+				{name: "c-isolated", a: []string{"c"}, b: []string{"a", "b"}},
+			},
+		},
+		{
+			name:     "majority minority of 3",
+			pods:     []string{"a", "b", "c"},
+			topology: scenario.TopologyMajorityMinority,
+			exp: []partition{
+				{name: "minority-a", a: []string{"a"}, b: []string{"b", "c"}},
+				{name: "minority-b", a: []string{"b"}, b: []string{"a", "c"}},
+				{name: "minority-c", a: []string{"c"}, b: []string{"a", "b"}},
+			},
+		},
+		{
+			name:     "majority minority of 5",
+			pods:     []string{"a", "b", "c", "d", "e"},
+			topology: scenario.TopologyMajorityMinority,
+			exp: []partition{
+				{name: "minority-a", a: []string{"a", "b"}, b: []string{"c", "d", "e"}},
+				{name: "minority-b", a: []string{"b", "c"}, b: []string{"a", "d", "e"}},
+				{name: "minority-c", a: []string{"c", "d"}, b: []string{"a", "b", "e"}},
+				{name: "minority-d", a: []string{"d", "e"}, b: []string{"a", "b", "c"}},
+				{name: "minority-e", a: []string{"e", "a"}, b: []string{"b", "c", "d"}},
+			},
+		},
+		{
+			name:     "majority minority of 2",
+			pods:     []string{"a", "b"},
+			topology: scenario.TopologyMajorityMinority,
+			expErr:   "at least 3 pods are required for a majority/minority partition",
+		},
+		{
+			name:     "bridge",
+			pods:     []string{"a", "b", "c", "d", "e"},
+			topology: scenario.TopologyBridge,
+			exp: []partition{
+				{name: "a-bridge", a: []string{"b", "c"}, b: []string{"d", "e"}},
+				{name: "b-bridge", a: []string{"a", "c"}, b: []string{"d", "e"}},
+				{name: "c-bridge", a: []string{"a", "b"}, b: []string{"d", "e"}},
+				{name: "d-bridge", a: []string{"a", "b"}, b: []string{"c", "e"}},
+				{name: "e-bridge", a: []string{"a", "b"}, b: []string{"c", "d"}},
+			},
+		},
+		{
+			name:     "bridge of 2",
+			pods:     []string{"a", "b"},
+			topology: scenario.TopologyBridge,
+			expErr:   "at least 3 pods are required for a bridge partition",
+		},
+		{
+			name:     "single pod",
+			pods:     []string{"a"},
+			topology: scenario.TopologyIsolate,
+			expErr:   "at least 2 pods are required to partition",
+		},
+		{
+			name:     "single domain",
+			pods:     []string{"us-east-1a"},
+			noun:     "domains",
+			topology: scenario.TopologyPairs,
+			expErr:   "at least 2 domains are required to partition",
+		},
+		{
+			name:     "unsupported topology",
+			pods:     []string{"a", "b"},
+			topology: "ring",
+			expErr:   `unsupported topology: "ring"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			noun := c.noun
+			if noun == "" {
+				noun = "pods"
+			}
+
+			act, err := partitions(c.pods, noun, c.topology, rand.New(rand.NewPCG(1, 0)))
+			if c.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expErr) {
+					t.Fatalf("expected error containing %q, got %v", c.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(act, c.exp) {
+				t.Fatalf("expected %+v, got %+v", c.exp, act)
+			}
+		})
+	}
+}
+
+func TestPartitionsRandomHalves(t *testing.T) {
+	pods := []string{"a", "b", "c", "d", "e"}
+
+	first, err := partitions(pods, "pods", scenario.TopologyRandomHalves, rand.New(rand.NewPCG(42, 0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("expected 1 partition, got %d", len(first))
+	}
+
+	p := first[0]
+	if len(p.a) != 2 || len(p.b) != 3 {
+		t.Fatalf("expected halves of 2 and 3 pods, got %v and %v", p.a, p.b)
+	}
+
+	all := slices.Concat(p.a, p.b)
+	slices.Sort(all)
+	if !slices.Equal(all, pods) {
+		t.Fatalf("expected halves to cover %v, got %v", pods, all)
+	}
+
+	second, err := partitions(pods, "pods", scenario.TopologyRandomHalves, rand.New(rand.NewPCG(42, 0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("expected the same seed to give the same halves, got %+v and %+v", first, second)
+	}
+}
@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"github.com/codingconcepts/db-chaos/pkg/proxy"
+)
+
+// StartProxies starts a built-in proxy for each database node, for the
+// proxy backend. It must be called before the workload connects through the
+// proxies, which run until the process exits.
+func StartProxies(p *scenario.Proxy) (map[string]*proxy.Proxy, error) {
+	if p == nil {
+		return nil, fmt.Errorf("the scenario has no proxy configuration")
+	}
+
+	proxies := map[string]*proxy.Proxy{}
+	for _, node := range p.Nodes {
+		px, err := proxy.Listen(node.Listen, node.Upstream)
+		if err != nil {
+			for _, started := range proxies {
+				started.Close()
+			}
+			return nil, fmt.Errorf("starting proxy %s: %w", node.Name, err)
+		}
+
+		proxies[node.Name] = px
+		log.Printf("[%s] proxying %s on %s to %s", yellow("chaos"), node.Name, px.Addr(), node.Upstream)
+	}
+
+	return proxies, nil
+}
+
+// proxyInjector injects faults into the traffic between the workload and
+// each database node using the built-in proxies. As traffic between nodes
+// doesn't pass through the proxies, faults only affect the workload's view
+// of the nodes.
+type proxyInjector struct {
+	proxies map[string]*proxy.Proxy
+}
+
+func (i *proxyInjector) Supports(expType string) bool {
+	switch expType {
+	case scenario.TypeNetworkDelay, scenario.TypeNetworkTimeout, scenario.TypeNetworkDrop, scenario.TypeNetworkHalfOpen, scenario.TypeNetworkSlowClose, scenario.TypePartition:
+		return true
+	default:
+		return false
+	}
+}
+
+func (i *proxyInjector) Validate(exp scenario.Experiment) error {
+	if len(exp.Peers) > 0 {
+		return fmt.Errorf("peers aren't supported by the %s backend", BackendProxy)
+	}
+	if exp.Type == scenario.TypeNetworkTimeout && exp.Timeout != nil && exp.Timeout.After > 0 {
+		return fmt.Errorf("timeout after isn't supported by the %s backend, which holds stalled connections open", BackendProxy)
+	}
+	return nil
+}
+
+func (i *proxyInjector) Inject(f Fault) (Injection, error) {
+	faults, err := proxyFaults(f.Experiment)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	// As with Toxiproxy, partitions only cut the workload off from the
+	// first side.
+	var proxies []*proxy.Proxy
+	for _, node := range f.Pods {
+		px, ok := i.proxies[node]
+		if !ok {
+			return Injection{}, fmt.Errorf("no proxy for node %s", node)
+		}
+		proxies = append(proxies, px)
+	}
+
+	for _, px := range proxies {
+		for _, stream := range streams(f.Experiment.Direction) {
+			px.Add(f.Name, proxy.Direction(stream), faults)
+		}
+	}
+	injected := time.Now()
+
+	return Injection{
+		Kind: "Proxy",
+		Wait: func() (time.Time, error) { return injected, nil },
+		Recover: func() (time.Time, error) {
+			for _, px := range proxies {
+				px.Remove(f.Name)
+			}
+			return time.Now(), nil
+		},
+	}, nil
+}
+
+// proxyFaults returns the proxy faults that inject an experiment's fault.
+func proxyFaults(exp scenario.Experiment) (proxy.Faults, error) {
+	var after time.Duration
+	if exp.Timeout != nil {
+		after = exp.Timeout.After
+	}
+
+	switch exp.Type {
+	case scenario.TypeNetworkDelay:
+		return proxy.Faults{Delay: exp.Delay.Latency, Jitter: exp.Delay.Jitter}, nil
+
+	case scenario.TypeNetworkTimeout, scenario.TypePartition:
+		return proxy.Faults{Blackhole: true}, nil
+
+	case scenario.TypeNetworkDrop:
+		return proxy.Faults{Drop: true}, nil
+
+	case scenario.TypeNetworkHalfOpen:
+		return proxy.Faults{HalfOpen: true}, nil
+
+	case scenario.TypeNetworkSlowClose:
+		return proxy.Faults{SlowClose: after}, nil
+
+	default:
+		return proxy.Faults{}, fmt.Errorf("unsupported experiment type: %q", exp.Type)
+	}
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"gopkg.in/yaml.v3"
+)
+
+// Render returns every Chaos Mesh object the scenario would create as a
+// multi-document YAML stream, without applying anything. If the runner is
+// configured to run a workflow, the workflow is rendered instead.
+func (r *ChaosRunner) Render() ([]byte, error) {
+	err := r.requireChaosMesh("rendering")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = r.Pods(); err != nil {
+		return nil, err
+	}
+
+	objects, err := r.renderObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	names := map[string]bool{}
+	for _, obj := range objects {
+		// Label objects as db-chaos would, so that anything applied
+		// manually can still be removed with the cleanup command.
+		md := obj.GetMetadata()
+		md.Labels = r.labels()
+
+		// Repeated experiments create the same objects each time, which
+		// would overwrite each other when applied, so number the repeats.
+		name := md.Name
+		for i := 2; names[md.Name]; i++ {
+			md.Name = fmt.Sprintf("%s-%d", name, i)
+		}
+		names[md.Name] = true
+
+		if err = enc.Encode(obj); err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", activeKey(obj), err)
+		}
+	}
+
+	if err = enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding objects: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r *ChaosRunner) renderObjects() ([]chaos.Object, error) {
+	if r.opts.Workflow {
+		b, err := r.compileWorkflow()
+		if err != nil {
+			return nil, err
+		}
+
+		workflow, err := r.makeWorkflow(b)
+		if err != nil {
+			return nil, err
+		}
+		return []chaos.Object{&workflow}, nil
+	}
+
+	r.rendering = true
+	defer func() {
+		r.rendering = false
+		r.rendered = nil
+	}()
+
+	if err := r.runScenario(); err != nil {
+		return nil, fmt.Errorf("rendering scenario: %w", err)
+	}
+
+	return r.rendered, nil
+}
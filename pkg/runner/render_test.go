@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		name     string
+		scenario string
+		workflow bool
+	}{
+		{name: "default"},
+		{name: "default-workflow", workflow: true},
+		{name: "repeat", scenario: "repeat.yaml"},
+		{name: "concurrent", scenario: "concurrent.yaml"},
+		{name: "network", scenario: "network.yaml"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := scenario.Default(time.Minute)
+			if c.scenario != "" {
+				var err error
+				if s, err = scenario.Load(filepath.Join("testdata", "render", c.scenario), time.Minute); err != nil {
+					t.Fatalf("loading scenario: %v", err)
+				}
+			}
+
+			opts := ChaosOptions{
+				Namespace:      "crdb",
+				ChaosNamespace: "chaos-mesh",
+				Backend:        BackendChaosMesh,
+				Pods:           []string{"a-0", "a-1", "a-2"},
+				Workflow:       c.workflow,
+				DryRun:         true,
+				Seed:           1,
+			}
+
+			r, err := NewChaosRunner(nil, s, opts, nil)
+			if err != nil {
+				t.Fatalf("creating runner: %v", err)
+			}
+			r.runID = "test"
+
+			act, err := r.Render()
+			if err != nil {
+				t.Fatalf("rendering: %v", err)
+			}
+
+			assertUniqueNames(t, act)
+
+			golden := filepath.Join("testdata", "render", c.name+".golden.yaml")
+			if *update {
+				if err = os.WriteFile(golden, act, 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+			}
+
+			exp, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+
+			if !bytes.Equal(act, exp) {
+				t.Fatalf("rendered manifests don't match %s (run with -update to update):\n%s", golden, act)
+			}
+		})
+	}
+}
+
+// assertUniqueNames fails if any two rendered objects share a kind and name,
+// as they'd overwrite each other when applied.
+func assertUniqueNames(t *testing.T, manifests []byte) {
+	t.Helper()
+
+	seen := map[string]bool{}
+	dec := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		var obj struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := dec.Decode(&obj); err != nil {
+			break
+		}
+
+		key := obj.Kind + "/" + obj.Metadata.Name
+		if seen[key] {
+			t.Fatalf("duplicate object %s", key)
+		}
+		seen[key] = true
+	}
+
+	if len(seen) == 0 {
+		t.Fatal("no objects rendered")
+	}
+}
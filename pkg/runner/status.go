@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// chaosStatus is the part of a Chaos Mesh object's status that reports
+// whether chaos has been injected into, and recovered from, its targets.
+type chaosStatus struct {
+	Conditions []chaosCondition `json:"conditions"`
+	Experiment struct {
+		Records []chaosRecord `json:"containerRecords"`
+	} `json:"experiment"`
+}
+
+type chaosCondition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// chaosRecord describes the chaos injected into a single target.
+type chaosRecord struct {
+	ID     string        `json:"id"`
+	Phase  string        `json:"phase"`
+	Events []recordEvent `json:"events"`
+}
+
+type recordEvent struct {
+	Type      string    `json:"type"`
+	Operation string    `json:"operation"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (s chaosStatus) condition(condition string) bool {
+	return lo.ContainsBy(s.Conditions, func(c chaosCondition) bool {
+		return c.Type == condition && c.Status == "True"
+	})
+}
+
+// last returns the time of the last successful operation (Apply or
+// Recover) across all records, or the current time if none were reported.
+func (s chaosStatus) last(operation string) time.Time {
+	var last time.Time
+	for _, record := range s.Experiment.Records {
+		for _, e := range record.Events {
+			if e.Operation == operation && e.Type == "Succeeded" && e.Timestamp.After(last) {
+				last = e.Timestamp
+			}
+		}
+	}
+
+	if last.IsZero() {
+		return time.Now()
+	}
+	return last
+}
+
+// describe explains why chaos hasn't reached the given phase.
+func (s chaosStatus) describe(phase string) string {
+	if len(s.Conditions) > 0 && !s.condition("Selected") {
+		return "no target pods were selected"
+	}
+
+	var details []string
+	for _, record := range s.Experiment.Records {
+		if record.Phase == phase {
+			continue
+		}
+
+		detail := fmt.Sprintf("%s is %s", record.ID, strings.ToLower(record.Phase))
+		for _, e := range slices.Backward(record.Events) {
+			if e.Type == "Failed" {
+				detail += fmt.Sprintf(" (%s failed: %s)", strings.ToLower(e.Operation), e.Message)
+				break
+			}
+		}
+		details = append(details, detail)
+	}
+
+	if len(details) == 0 {
+		return "no status reported by chaos mesh"
+	}
+	return strings.Join(details, ", ")
+}
+
+func getChaosStatus(dr dynamic.ResourceInterface, name string) (chaosStatus, error) {
+	obj, err := dr.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return chaosStatus{}, err
+	}
+
+	var status chaosStatus
+	b, err := json.Marshal(obj.Object["status"])
+	if err != nil {
+		return chaosStatus{}, fmt.Errorf("marshalling status: %w", err)
+	}
+	if err = json.Unmarshal(b, &status); err != nil {
+		return chaosStatus{}, fmt.Errorf("parsing status: %w", err)
+	}
+
+	return status, nil
+}
+
+// waitForInjection waits for Chaos Mesh to report that chaos has been
+// injected into every target and returns the time it was injected.
+func waitForInjection(dr dynamic.ResourceInterface, name string, wait time.Duration) (time.Time, error) {
+	timeout := time.After(wait)
+	check := time.Tick(time.Second)
+
+	var status chaosStatus
+	for {
+		select {
+		case <-check:
+			var err error
+			if status, err = getChaosStatus(dr, name); err != nil {
+				return time.Time{}, fmt.Errorf("getting status of %s: %w", name, err)
+			}
+
+			if status.condition("AllInjected") {
+				return status.last("Apply"), nil
+			}
+
+		case <-timeout:
+			return time.Time{}, fmt.Errorf("chaos %s wasn't injected within %s: %s", name, wait, status.describe("Injected"))
+		}
+	}
+}
+
+// waitForRecovery waits for Chaos Mesh to report that deleted chaos has
+// been recovered from on every target, which it does before the object is
+// removed, and returns the time it was recovered.
+func waitForRecovery(dr dynamic.ResourceInterface, name string, wait time.Duration) (time.Time, error) {
+	timeout := time.After(wait)
+	check := time.Tick(time.Second)
+
+	var status chaosStatus
+	for {
+		select {
+		case <-check:
+			latest, err := getChaosStatus(dr, name)
+			if k8serrors.IsNotFound(err) {
+				return status.last("Recover"), nil
+			}
+			if err != nil {
+				return time.Time{}, fmt.Errorf("getting status of %s: %w", name, err)
+			}
+			status = latest
+
+			if status.condition("AllRecovered") {
+				return status.last("Recover"), nil
+			}
+
+		case <-timeout:
+			return time.Time{}, fmt.Errorf("chaos %s wasn't recovered within %s: %s", name, wait, status.describe("Not Injected"))
+		}
+	}
+}
+
+// ActiveDurations returns the total time that each experiment's chaos was
+// confirmed to be active, counting overlapping chaos once.
+func ActiveDurations(timeline []Event) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+
+	for name, events := range lo.GroupBy(timeline, func(e Event) string { return e.Experiment }) {
+		events = lo.Filter(events, func(e Event, _ int) bool {
+			return !e.Injected.IsZero() && e.Recovered.After(e.Injected)
+		})
+		slices.SortFunc(events, func(a, b Event) int { return a.Injected.Compare(b.Injected) })
+
+		var total time.Duration
+		var end time.Time
+		for _, e := range events {
+			start := e.Injected
+			if start.Before(end) {
+				start = end
+			}
+			if e.Recovered.After(start) {
+				total += e.Recovered.Sub(start)
+				end = e.Recovered
+			}
+		}
+
+		durations[name] = total
+	}
+
+	return durations
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActiveDurations(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+
+	cases := []struct {
+		name     string
+		timeline []Event
+		exp      map[string]time.Duration
+	}{
+		{
+			name: "empty",
+			exp:  map[string]time.Duration{},
+		},
+		{
+			name: "sequential",
+			timeline: []Event{
+				{Experiment: "kill", Injected: at(0), Recovered: at(10)},
+				{Experiment: "kill", Injected: at(20), Recovered: at(25)},
+			},
+			exp: map[string]time.Duration{"kill": 15 * time.Second},
+		},
+		{
+			name: "overlapping counted once",
+			timeline: []Event{
+				{Experiment: "group", Injected: at(0), Recovered: at(10)},
+				{Experiment: "group", Injected: at(5), Recovered: at(15)},
+			},
+			exp: map[string]time.Duration{"group": 15 * time.Second},
+		},
+		{
+			name: "contained",
+			timeline: []Event{
+				{Experiment: "group", Injected: at(0), Recovered: at(20)},
+				{Experiment: "group", Injected: at(5), Recovered: at(10)},
+			},
+			exp: map[string]time.Duration{"group": 20 * time.Second},
+		},
+		{
+			name: "unordered",
+			timeline: []Event{
+				{Experiment: "group", Injected: at(30), Recovered: at(40)},
+				{Experiment: "group", Injected: at(0), Recovered: at(10)},
+			},
+			exp: map[string]time.Duration{"group": 20 * time.Second},
+		},
+		{
+			name: "unconfirmed ignored",
+			timeline: []Event{
+				{Experiment: "partition", Injected: at(0), Recovered: at(10)},
+				{Experiment: "partition", Applied: at(20), Deleted: at(30)},
+				{Experiment: "workflow", Applied: at(0), Deleted: at(30)},
+			},
+			exp: map[string]time.Duration{"partition": 10 * time.Second, "workflow": 0},
+		},
+		{
+			name: "per experiment",
+			timeline: []Event{
+				{Experiment: "kill", Injected: at(0), Recovered: at(10)},
+				{Experiment: "partition", Injected: at(5), Recovered: at(8)},
+			},
+			exp: map[string]time.Duration{"kill": 10 * time.Second, "partition": 3 * time.Second},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if act := ActiveDurations(c.timeline); !reflect.DeepEqual(act, c.exp) {
+				t.Fatalf("expected %v, got %v", c.exp, act)
+			}
+		})
+	}
+}
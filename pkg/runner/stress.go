@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// StressChaos applies CPU and/or memory pressure to the target pods, leaving
+// them running but overloaded.
+func (r *ChaosRunner) StressChaos(pods []string, exp scenario.Experiment) error {
+	var stressors chaos.Stressors
+	if cpu := exp.Stress.CPU; cpu != nil {
+		stressors.CPU = &chaos.CPUStressor{Workers: cpu.Workers, Load: cpu.Load}
+	}
+	if mem := exp.Stress.Memory; mem != nil {
+		stressors.Memory = &chaos.MemoryStressor{Workers: mem.Workers, Size: mem.Size}
+	}
+
+	return r.eachTarget(pods, exp, func(name string, pods []string) (chaos.Object, error) {
+		chaos := chaos.MakeStressChaos(name, pods, r.opts.Namespace, r.opts.ChaosNamespace, stressors, exp.Duration)
+		return &chaos, nil
+	})
+}
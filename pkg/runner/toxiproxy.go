@@ -0,0 +1,305 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/scenario"
+)
+
+// toxicPrefix is prepended to the names of every toxic created by db-chaos,
+// so that toxics left behind by a killed run can be found and removed.
+const toxicPrefix = "db-chaos-"
+
+// toxiproxy is a client for the Toxiproxy HTTP API.
+type toxiproxy struct {
+	url    string
+	client *http.Client
+}
+
+type toxiproxyProxy struct {
+	Name     string  `json:"name"`
+	Listen   string  `json:"listen"`
+	Upstream string  `json:"upstream"`
+	Enabled  bool    `json:"enabled"`
+	Toxics   []toxic `json:"toxics,omitempty"`
+}
+
+// toxic is a fault applied to a proxy's traffic in one direction. Upstream
+// traffic flows from the client to the database and downstream traffic back
+// again.
+type toxic struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Stream     string           `json:"stream"`
+	Toxicity   float64          `json:"toxicity"`
+	Attributes map[string]int64 `json:"attributes"`
+}
+
+func newToxiproxy(url string) *toxiproxy {
+	return &toxiproxy{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (t *toxiproxy) do(method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling request: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, t.url+path, r)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (t *toxiproxy) proxies() (map[string]toxiproxyProxy, error) {
+	var proxies map[string]toxiproxyProxy
+	if err := t.do(http.MethodGet, "/proxies", nil, &proxies); err != nil {
+		return nil, fmt.Errorf("listing proxies: %w", err)
+	}
+	return proxies, nil
+}
+
+func (t *toxiproxy) addToxic(proxy string, tx toxic) error {
+	if err := t.do(http.MethodPost, "/proxies/"+proxy+"/toxics", tx, nil); err != nil {
+		return fmt.Errorf("adding toxic %s to %s: %w", tx.Name, proxy, err)
+	}
+	return nil
+}
+
+func (t *toxiproxy) removeToxic(proxy, name string) error {
+	if err := t.do(http.MethodDelete, "/proxies/"+proxy+"/toxics/"+name, nil, nil); err != nil {
+		return fmt.Errorf("removing toxic %s from %s: %w", name, proxy, err)
+	}
+	return nil
+}
+
+// CreateProxies creates or updates a Toxiproxy proxy for each database node
+// and removes any toxics left behind by previous runs. It must be called
+// before the workload connects through the proxies.
+func CreateProxies(p *scenario.Proxy) error {
+	if p == nil {
+		return fmt.Errorf("the scenario has no proxy configuration")
+	}
+
+	t := newToxiproxy(p.URL)
+	existing, err := t.proxies()
+	if err != nil {
+		return err
+	}
+
+	for _, node := range p.Nodes {
+		proxy := toxiproxyProxy{
+			Name:     node.Name,
+			Listen:   node.Listen,
+			Upstream: node.Upstream,
+			Enabled:  true,
+		}
+
+		path := "/proxies"
+		if _, ok := existing[node.Name]; ok {
+			path += "/" + node.Name
+		}
+		if err = t.do(http.MethodPost, path, proxy, nil); err != nil {
+			return fmt.Errorf("creating proxy %s: %w", node.Name, err)
+		}
+
+		for _, tx := range existing[node.Name].Toxics {
+			if !strings.HasPrefix(tx.Name, toxicPrefix) {
+				continue
+			}
+			if err = t.removeToxic(node.Name, tx.Name); err != nil {
+				return err
+			}
+			log.Printf("[%s] removed toxic left behind by a previous run: %s/%s", yellow("chaos"), node.Name, tx.Name)
+		}
+
+		log.Printf("[%s] proxying %s on %s to %s", yellow("chaos"), node.Name, node.Listen, node.Upstream)
+	}
+
+	return nil
+}
+
+// toxiproxyInjector injects faults into the traffic between the workload
+// and each database node. As traffic between nodes doesn't pass through the
+// proxies, faults only affect the workload's view of the nodes.
+type toxiproxyInjector struct {
+	client *toxiproxy
+}
+
+func (i *toxiproxyInjector) Supports(expType string) bool {
+	switch expType {
+	case scenario.TypeNetworkDelay, scenario.TypeNetworkBandwidth, scenario.TypeNetworkTimeout, scenario.TypeNetworkReset, scenario.TypeNetworkSlowClose, scenario.TypePartition:
+		return true
+	default:
+		return false
+	}
+}
+
+func (i *toxiproxyInjector) Inject(f Fault) (Injection, error) {
+	if len(f.Experiment.Peers) > 0 {
+		return Injection{}, fmt.Errorf("peers aren't supported by the %s backend", BackendToxiproxy)
+	}
+
+	toxicType, attributes, err := toxicFor(f.Experiment)
+	if err != nil {
+		return Injection{}, err
+	}
+
+	type added struct{ proxy, name string }
+	var toxics []added
+
+	remove := func() (time.Time, error) {
+		for _, tx := range toxics {
+			if err := i.client.removeToxic(tx.proxy, tx.name); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Now(), nil
+	}
+
+	// Partitions only cut the workload off from the first side, as that's
+	// the side faulted by each partition in turn.
+	for _, node := range f.Pods {
+		for _, stream := range streams(f.Experiment.Direction) {
+			tx := toxic{
+				Name:       fmt.Sprintf("%s%s-%s", toxicPrefix, f.Name, stream),
+				Type:       toxicType,
+				Stream:     stream,
+				Toxicity:   1,
+				Attributes: attributes,
+			}
+
+			if err = i.client.addToxic(node, tx); err != nil {
+				remove()
+				return Injection{}, err
+			}
+			toxics = append(toxics, added{proxy: node, name: tx.Name})
+		}
+	}
+
+	// Toxics apply to existing connections as soon as they're added.
+	injected := time.Now()
+
+	return Injection{
+		Kind:    "Toxic",
+		Wait:    func() (time.Time, error) { return injected, nil },
+		Recover: remove,
+	}, nil
+}
+
+// toxicFor returns the type and attributes of the toxic that injects an
+// experiment's fault.
+func toxicFor(exp scenario.Experiment) (string, map[string]int64, error) {
+	var after int64
+	if exp.Timeout != nil {
+		after = exp.Timeout.After.Milliseconds()
+	}
+
+	switch exp.Type {
+	case scenario.TypeNetworkDelay:
+		return "latency", map[string]int64{
+			"latency": exp.Delay.Latency.Milliseconds(),
+			"jitter":  exp.Delay.Jitter.Milliseconds(),
+		}, nil
+
+	case scenario.TypeNetworkBandwidth:
+		rate, err := bandwidthKBps(exp.Bandwidth.Rate)
+		if err != nil {
+			return "", nil, err
+		}
+		return "bandwidth", map[string]int64{"rate": rate}, nil
+
+	case scenario.TypeNetworkTimeout:
+		return "timeout", map[string]int64{"timeout": after}, nil
+
+	case scenario.TypeNetworkReset:
+		return "reset_peer", map[string]int64{"timeout": after}, nil
+
+	case scenario.TypeNetworkSlowClose:
+		return "slow_close", map[string]int64{"delay": after}, nil
+
+	case scenario.TypePartition:
+		// A timeout of zero stalls traffic without closing connections,
+		// which is how a partition appears to clients.
+		return "timeout", map[string]int64{"timeout": 0}, nil
+
+	default:
+		return "", nil, fmt.Errorf("unsupported experiment type: %q", exp.Type)
+	}
+}
+
+// streams returns the Toxiproxy streams affected by a direction, from the
+// point of view of the database nodes.
+func streams(direction string) []string {
+	switch direction {
+	case "to":
+		return []string{"downstream"}
+	case "from":
+		return []string{"upstream"}
+	default:
+		return []string{"upstream", "downstream"}
+	}
+}
+
+// bandwidthUnits are the tc rate units, longest suffix first. Note that tc
+// rates ending in bps are in bytes, not bits, per second.
+var bandwidthUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"tbps", 1e12}, {"gbps", 1e9}, {"mbps", 1e6}, {"kbps", 1e3}, {"bps", 1},
+}
+
+// bandwidthKBps converts a tc bandwidth rate (e.g. 1mbps) into the KB/s
+// expected by Toxiproxy, rounding up to at least 1 KB/s.
+func bandwidthKBps(rate string) (int64, error) {
+	for _, unit := range bandwidthUnits {
+		digits, ok := strings.CutSuffix(rate, unit.suffix)
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bandwidth rate %q: %w", rate, err)
+		}
+		return max(n*unit.bytes/1000, 1), nil
+	}
+
+	return 0, fmt.Errorf("invalid bandwidth rate %q", rate)
+}
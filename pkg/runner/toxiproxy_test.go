@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBandwidthKBps(t *testing.T) {
+	cases := []struct {
+		name   string
+		rate   string
+		exp    int64
+		expErr string
+	}{
+		{name: "bytes", rate: "5000bps", exp: 5},
+		{name: "kilobytes", rate: "100kbps", exp: 100},
+		{name: "megabytes", rate: "1mbps", exp: 1000},
+		{name: "gigabytes", rate: "2gbps", exp: 2_000_000},
+		{name: "terabytes", rate: "1tbps", exp: 1_000_000_000},
+		{name: "below 1 KB/s", rate: "10bps", exp: 1},
+		{name: "zero", rate: "0kbps", exp: 1},
+		{name: "no unit", rate: "100", expErr: `invalid bandwidth rate "100"`},
+		{name: "bits", rate: "100mbit", expErr: `invalid bandwidth rate "100mbit"`},
+		{name: "no number", rate: "mbps", expErr: `invalid bandwidth rate "mbps"`},
+		{name: "fraction", rate: "1.5mbps", expErr: `invalid bandwidth rate "1.5mbps"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act, err := bandwidthKBps(c.rate)
+			if c.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.expErr) {
+					t.Fatalf("expected error containing %q, got %v", c.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if act != c.exp {
+				t.Fatalf("expected %d KB/s, got %d", c.exp, act)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/db-chaos/pkg/model/chaos"
+	"github.com/samber/lo"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	workflowGVR     = schema.GroupVersionResource{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "workflows"}
+	workflowNodeGVR = schema.GroupVersionResource{Group: "chaos-mesh.org", Version: "v1alpha1", Resource: "workflownodes"}
+)
+
+// workflowLabel is applied by Chaos Mesh to the nodes of a workflow.
+const workflowLabel = "chaos-mesh.org/workflow"
+
+// Annotations that record which experiment each of a workflow's templates
+// belongs to, so that a workflow can be followed by another process.
+const (
+	experimentsAnnotation = "db-chaos/experiments"
+	chaosAnnotation       = "db-chaos/chaos"
+)
+
+// workflowBuilder collects the templates of a workflow while the scenario is
+// compiled, in place of applying each experiment.
+type workflowBuilder struct {
+	templates []chaos.Template
+	names     map[string]bool
+
+	// children is a stack of the children of the groups being built, with
+	// the entry template's children at the bottom.
+	children [][]string
+
+	// experiments maps the templates that group the chaos of each
+	// experiment to the experiment's name, and chaos maps the chaos
+	// templates to the experiment they belong to.
+	experiments map[string]string
+	chaos       map[string]string
+	current     string
+}
+
+func newWorkflowBuilder() *workflowBuilder {
+	return &workflowBuilder{
+		names:       map[string]bool{},
+		children:    [][]string{nil},
+		experiments: map[string]string{},
+		chaos:       map[string]string{},
+	}
+}
+
+// add adds a template to the group being built and returns its name, which
+// is made unique within the workflow.
+func (b *workflowBuilder) add(t chaos.Template) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(t.Name), "-"), "-")
+	t.Name = name
+	for i := 2; b.names[t.Name]; i++ {
+		t.Name = fmt.Sprintf("%s-%d", name, i)
+	}
+	b.names[t.Name] = true
+
+	b.templates = append(b.templates, t)
+
+	last := len(b.children) - 1
+	b.children[last] = append(b.children[last], t.Name)
+	return t.Name
+}
+
+// group adds a Serial or Parallel template whose children are the templates
+// added by fn.
+func (b *workflowBuilder) group(name, templateType string, fn func() error) error {
+	_, err := b.build(name, templateType, fn)
+	return err
+}
+
+// experiment adds a Serial template containing the chaos of an experiment.
+func (b *workflowBuilder) experiment(name string, fn func() error) error {
+	b.current = name
+	defer func() { b.current = "" }()
+
+	template, err := b.build(name, chaos.TemplateSerial, fn)
+	if err != nil {
+		return err
+	}
+
+	b.experiments[template] = name
+	return nil
+}
+
+func (b *workflowBuilder) build(name, templateType string, fn func() error) (string, error) {
+	b.children = append(b.children, nil)
+	err := fn()
+
+	last := len(b.children) - 1
+	children := b.children[last]
+	b.children = b.children[:last]
+
+	if err != nil {
+		return "", err
+	}
+
+	return b.add(chaos.MakeGroupTemplate(name, templateType, children)), nil
+}
+
+func (b *workflowBuilder) inject(obj chaos.Object, duration time.Duration) error {
+	t, err := chaos.MakeChaosTemplate(obj.GetMetadata().Name, obj, duration)
+	if err != nil {
+		return err
+	}
+
+	b.chaos[b.add(t)] = b.current
+	return nil
+}
+
+func (b *workflowBuilder) suspend(duration time.Duration) {
+	b.add(chaos.MakeSuspendTemplate("pause", duration))
+}
+
+func (b *workflowBuilder) spec() chaos.WorkflowSpec {
+	entry := chaos.MakeGroupTemplate("entry", chaos.TemplateSerial, b.children[0])
+	entry.Name = b.add(entry)
+
+	return chaos.WorkflowSpec{
+		Entry:     entry.Name,
+		Templates: b.templates,
+	}
+}
+
+// compileWorkflow builds a workflow that runs the scenario by running it
+// without applying anything.
+func (r *ChaosRunner) compileWorkflow() (*workflowBuilder, error) {
+	r.workflow = newWorkflowBuilder()
+	defer func() { r.workflow = nil }()
+
+	b := r.workflow
+	if err := r.runScenario(); err != nil {
+		return nil, fmt.Errorf("compiling scenario: %w", err)
+	}
+
+	return b, nil
+}
+
+// makeWorkflow returns the Workflow for a compiled scenario, annotated so
+// that it can be followed by a later run.
+func (r *ChaosRunner) makeWorkflow(b *workflowBuilder) (chaos.Workflow, error) {
+	workflow := chaos.MakeWorkflow(r.workflowName(), r.opts.ChaosNamespace, b.spec())
+
+	experiments, err := json.Marshal(b.experiments)
+	if err != nil {
+		return chaos.Workflow{}, fmt.Errorf("encoding workflow experiments: %w", err)
+	}
+
+	faults, err := json.Marshal(b.chaos)
+	if err != nil {
+		return chaos.Workflow{}, fmt.Errorf("encoding workflow chaos: %w", err)
+	}
+
+	workflow.Metadata.Annotations = map[string]string{
+		experimentsAnnotation: string(experiments),
+		chaosAnnotation:       string(faults),
+	}
+
+	return workflow, nil
+}
+
+// runWorkflow submits the scenario as a single Workflow and follows its
+// progress until it's accomplished. The workflow isn't removed by Cleanup,
+// so it carries on if the run is interrupted and can be followed again with
+// ResumeWorkflow. It's also left in place once it's accomplished, so that it
+// can be inspected in the Chaos Dashboard.
+func (r *ChaosRunner) runWorkflow() error {
+	b, err := r.compileWorkflow()
+	if err != nil {
+		return err
+	}
+
+	workflow, err := r.makeWorkflow(b)
+	if err != nil {
+		return err
+	}
+
+	if _, err = applyExperiment(r.kubeRestConfig, r.kubeClientDyn, &workflow, r.labels()); err != nil {
+		return fmt.Errorf("applying workflow: %w", err)
+	}
+	log.Printf("[%s] submitted workflow: %s (follow it again with --resume-workflow %s)", yellow("chaos"), workflow.Metadata.Name, r.runID)
+
+	if err = r.follow(workflow.Metadata.Name, b, false); err != nil {
+		return fmt.Errorf("following workflow: %w", err)
+	}
+
+	log.Printf("[%s] workflow accomplished: %s", yellow("chaos"), workflow.Metadata.Name)
+	return nil
+}
+
+// resumeWorkflow follows a workflow submitted by an earlier run, given its
+// name or run ID, adopting the earlier run's ID. Experiments that finished
+// before it was resumed aren't recorded by the workload.
+func (r *ChaosRunner) resumeWorkflow(nameOrRunID string) error {
+	name := nameOrRunID
+	if !strings.HasPrefix(name, "db-chaos-") {
+		name = "db-chaos-" + name
+	}
+
+	workflow, err := r.kubeClientDyn.Resource(workflowGVR).Namespace(r.opts.ChaosNamespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting workflow %s: %w", name, err)
+	}
+
+	annotations := workflow.GetAnnotations()
+	if _, ok := annotations[experimentsAnnotation]; !ok {
+		return fmt.Errorf("workflow %s wasn't submitted by db-chaos", name)
+	}
+
+	b := newWorkflowBuilder()
+	if err = json.Unmarshal([]byte(annotations[experimentsAnnotation]), &b.experiments); err != nil {
+		return fmt.Errorf("decoding workflow experiments: %w", err)
+	}
+	if err = json.Unmarshal([]byte(annotations[chaosAnnotation]), &b.chaos); err != nil {
+		return fmt.Errorf("decoding workflow chaos: %w", err)
+	}
+
+	if runID, ok := workflow.GetLabels()[runIDLabel]; ok {
+		r.runID = runID
+	}
+	log.Printf("[%s] resumed workflow: %s", yellow("chaos"), name)
+
+	if err = r.follow(name, b, true); err != nil {
+		return fmt.Errorf("following workflow: %w", err)
+	}
+
+	log.Printf("[%s] workflow accomplished: %s", yellow("chaos"), name)
+	return nil
+}
+
+// SubmitSchedule submits a Schedule that runs the scenario as a Workflow at
+// the times given by a cron expression. The schedule outlives the run and
+// can be removed with the cleanup command.
+func (r *ChaosRunner) SubmitSchedule(cron string) (string, error) {
+	err := r.requireChaosMesh("submitting a schedule")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = r.Pods(); err != nil {
+		return "", err
+	}
+
+	b, err := r.compileWorkflow()
+	if err != nil {
+		return "", err
+	}
+
+	schedule := chaos.MakeSchedule(r.workflowName(), r.opts.ChaosNamespace, cron, b.spec())
+	if _, err = applyExperiment(r.kubeRestConfig, r.kubeClientDyn, &schedule, r.labels()); err != nil {
+		return "", fmt.Errorf("applying schedule: %w", err)
+	}
+
+	return schedule.Metadata.Name, nil
+}
+
+func (r *ChaosRunner) workflowName() string {
+	return fmt.Sprintf("db-chaos-%s", r.runID)
+}
+
+// follow polls the nodes of a workflow until it's accomplished, notifying
+// the workload as each experiment starts and finishes and recording the
+// chaos injected. When resuming, experiments that had already finished
+// aren't notified, as the workload wasn't running during them.
+func (r *ChaosRunner) follow(name string, b *workflowBuilder, resumed bool) error {
+	workflows := r.kubeClientDyn.Resource(workflowGVR).Namespace(r.opts.ChaosNamespace)
+	nodes := r.kubeClientDyn.Resource(workflowNodeGVR).Namespace(r.opts.ChaosNamespace)
+
+	started := map[string]time.Time{}
+	notified := map[string]bool{}
+	finished := map[string]bool{}
+
+	check := time.Tick(time.Second * 5)
+	for first := true; ; first = false {
+		<-check
+
+		workflow, err := workflows.Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return errStopped
+			}
+			return fmt.Errorf("getting workflow: %w", err)
+		}
+
+		list, err := nodes.List(context.Background(), metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", workflowLabel, name),
+		})
+		if err != nil {
+			return fmt.Errorf("listing workflow nodes: %w", err)
+		}
+
+		// Process nodes in the order they were created, so that each
+		// experiment finishes before the next one starts.
+		items := list.Items
+		slices.SortStableFunc(items, func(a, b unstructured.Unstructured) int {
+			return a.GetCreationTimestamp().Compare(b.GetCreationTimestamp().Time)
+		})
+
+		for _, node := range items {
+			nodeName := node.GetName()
+			template, _, _ := unstructured.NestedString(node.Object, "spec", "templateName")
+
+			done := hasCondition(node, "Accomplished") || hasCondition(node, "DeadlineExceed")
+
+			if _, ok := started[nodeName]; !ok {
+				started[nodeName] = node.GetCreationTimestamp().Time
+				if exp, ok := b.experiments[template]; ok && !(resumed && first && done) {
+					log.Printf("[%s] started %s", yellow("chaos"), exp)
+					r.notify <- exp
+					notified[nodeName] = true
+				}
+			}
+
+			if finished[nodeName] || !done {
+				continue
+			}
+			finished[nodeName] = true
+
+			if exp, ok := b.chaos[template]; ok {
+				kind, _, _ := unstructured.NestedString(node.Object, "spec", "type")
+				r.record(Event{
+					Experiment: exp,
+					Kind:       kind,
+					Name:       nodeName,
+					Applied:    started[nodeName],
+					Deleted:    time.Now(),
+				})
+			}
+
+			if exp, ok := b.experiments[template]; ok && notified[nodeName] {
+				log.Printf("[%s] finished %s", yellow("chaos"), exp)
+				r.notify <- ""
+			}
+		}
+
+		if hasCondition(*workflow, "Accomplished") {
+			return nil
+		}
+	}
+}
+
+// hasCondition returns true if a Chaos Mesh object has a status condition
+// of the given type that's true.
+func hasCondition(obj unstructured.Unstructured, condition string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	return lo.ContainsBy(conditions, func(c any) bool {
+		m, ok := c.(map[string]any)
+		return ok && m["type"] == condition && m["status"] == "True"
+	})
+}
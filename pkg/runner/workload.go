@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/rand/v2"
+	"sync"
 	"time"
 
+	"github.com/codingconcepts/db-chaos/pkg/histogram"
 	"github.com/codingconcepts/db-chaos/pkg/repo"
 	"github.com/fatih/color"
 	"github.com/samber/lo"
@@ -23,21 +26,83 @@ type WorkloadRunner struct {
 	Accounts       int
 	Active         int
 	InitialBalance float64
+
+	// Workers is the number of goroutines performing transfers.
+	Workers int
+
+	// Rate is the number of transfers to start per second, regardless of how
+	// long previous transfers have taken to complete.
+	Rate float64
 }
 
 type ExperimentStats struct {
 	ErrorCount int
 	Downtime   time.Duration
+
+	// Latency records the latency of successful transfers, measured from the
+	// time each transfer was scheduled to start.
+	Latency *histogram.Histogram
+
+	// Behind counts the transfers that started more than one interval after
+	// they were scheduled because all workers were busy, and MaxLag is the
+	// furthest behind schedule a transfer started.
+	Behind int
+	MaxLag time.Duration
+
+	// Recovery is the time between the experiment ending and the next
+	// successful transfer, and Pool is a snapshot of the connection pool
+	// taken as the experiment ended. Neither is recorded for the baseline.
+	Recovery time.Duration
+	Pool     repo.PoolStats
+}
+
+// BalanceCheck captures the outcome of verifying that no money has been
+// created or destroyed by the bank workload.
+type BalanceCheck struct {
+	Expected float64
+	Actual   float64
+	Drift    float64
+	Err      error
+}
+
+// Ok returns true if the balances could be summed and no drift was found.
+func (c BalanceCheck) Ok() bool {
+	return c.Err == nil && math.Abs(c.Drift) < balanceTolerance
 }
 
 type Results struct {
 	TotalErrors   int
 	TotalDowntime time.Duration
+	TotalBehind   int
 
-	Stats map[string]ExperimentStats
+	Stats      map[string]ExperimentStats
+	Invariants map[string]BalanceCheck
 }
 
-func (r *WorkloadRunner) Run(repo repo.Repo, notify <-chan string) (Results, error) {
+const (
+	// BaselineExperiment is the name under which stats are recorded while no
+	// chaos experiment is running.
+	BaselineExperiment = "baseline"
+
+	// FinalCheck is the name of the balance check performed once all chaos
+	// experiments have completed.
+	FinalCheck = "final"
+
+	balanceTolerance     = 0.01
+	balanceCheckAttempts = 5
+)
+
+// Run performs transfers until notify is closed, recording their results
+// against the experiment most recently sent on notify. Closing stop ends the
+// run early, as if notify had been closed.
+func (r *WorkloadRunner) Run(repo repo.Repo, notify <-chan string, stop <-chan struct{}) (Results, error) {
+	if r.Workers < 1 {
+		return Results{}, fmt.Errorf("at least one worker is required")
+	}
+	if r.Rate <= 0 {
+		return Results{}, fmt.Errorf("rate must be greater than zero")
+	}
+
 	if r.Reseed {
 		if err := repo.Deinit(); err != nil {
 			return Results{}, fmt.Errorf("error running deinit: %w", err)
@@ -55,60 +120,249 @@ func (r *WorkloadRunner) Run(repo repo.Repo, notify <-chan string) (Results, err
 		return Results{}, fmt.Errorf("error fetching ids ahead of test: %w", err)
 	}
 
-	var errorCount int
-	var totalDowntime time.Duration
+	c := &collector{
+		stats: map[string]ExperimentStats{},
+	}
+
+	// Balance checks run alongside the workload, so that a check retrying
+	// while the database recovers doesn't hold up the next experiment.
+	var checksMu sync.Mutex
+	var checks sync.WaitGroup
+	balanceChecks := map[string]BalanceCheck{}
+
+	interval := time.Duration(float64(time.Second) / r.Rate)
+	transfers := make(chan time.Time, r.Workers)
+	done := make(chan struct{})
+	go schedule(transfers, interval, done)
 
-	var currentExperiment string
-	experimentStats := map[string]ExperimentStats{}
+	var wg sync.WaitGroup
+	for range r.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scheduled := range transfers {
+				r.transfer(repo, c, accountIDs, scheduled, interval)
+			}
+		}()
+	}
 
-	// Perform a transfer every 100ms.
-	transfers := time.Tick(time.Millisecond * 100)
 	for {
+		var exp string
+		var ok bool
 		select {
-		case exp, ok := <-notify:
-			if !ok {
-				return Results{
-					TotalErrors:   errorCount,
-					TotalDowntime: totalDowntime,
-					Stats:         experimentStats,
-				}, nil
-			}
+		case exp, ok = <-notify:
+		case <-stop:
+		}
 
-			currentExperiment = exp
+		// Switch experiments straight away, so that transfers made while the
+		// balances are checked aren't recorded against one that's ended, and
+		// so that its recovery is measured from when it ended rather than
+		// from when the check finished.
+		ended := c.setExperiment(exp, time.Now())
 
-		case <-transfers:
-			ids := lo.Samples(accountIDs, 2)
+		// An experiment has finished, so check that it didn't break the
+		// bank.
+		if ended != "" {
+			c.setPool(ended, repo.PoolStats())
 
-			taken, err := repo.PerformTransfer(ids[0], ids[1], rand.Float64()*100)
-			if err != nil && !errors.Is(err, context.Canceled) {
-				log.Printf("error: %v", err)
-				errorCount++
-				totalDowntime += taken
+			checks.Add(1)
+			go func() {
+				defer checks.Done()
 
-				increment(experimentStats, currentExperiment, taken)
-			}
+				check := r.checkBalances(repo, ended)
+
+				checksMu.Lock()
+				defer checksMu.Unlock()
+				balanceChecks[ended] = check
+			}()
+		}
+
+		if !ok {
+			close(done)
+			wg.Wait()
+			checks.Wait()
+
+			balanceChecks[FinalCheck] = r.checkBalances(repo, FinalCheck)
+
+			return Results{
+				TotalErrors:   c.errorCount,
+				TotalDowntime: c.totalDowntime,
+				TotalBehind:   c.totalBehind,
+				Stats:         c.stats,
+				Invariants:    balanceChecks,
+			}, nil
+		}
+	}
+}
+
+// schedule sends the time each transfer should start at to the workers,
+// until done is closed. If the workers can't keep up, the schedule isn't
+// adjusted, so the latency of each transfer includes the time spent waiting
+// for a worker rather than hiding it (coordinated omission).
+func schedule(transfers chan<- time.Time, interval time.Duration, done <-chan struct{}) {
+	defer close(transfers)
+
+	next := time.Now()
+	for {
+		select {
+		case <-time.After(time.Until(next)):
+		case <-done:
+			return
+		}
+
+		select {
+		case transfers <- next:
+		case <-done:
+			return
+		}
+
+		next = next.Add(interval)
+	}
+}
+
+func (r *WorkloadRunner) transfer(repo repo.Repo, c *collector, accountIDs []any, scheduled time.Time, interval time.Duration) {
+	lag := time.Since(scheduled)
+	ids := lo.Samples(accountIDs, 2)
+
+	taken, err := repo.PerformTransfer(ids[0], ids[1], rand.Float64()*100)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("error: %v", err)
+	}
+
+	errorCount, totalDowntime := c.record(taken, time.Since(scheduled), lag, lag > interval, err)
 
-			latencyMS := fmt.Sprintf("%dms", taken.Milliseconds())
-			totalDowntimeS := fmt.Sprintf("%0.2fs", totalDowntime.Seconds())
+	latencyMS := fmt.Sprintf("%dms", taken.Milliseconds())
+	totalDowntimeS := fmt.Sprintf("%0.2fs", totalDowntime.Seconds())
 
-			fmt.Printf(
-				"latency: %s, errors: %s, total downtime: %s\n",
-				blue(latencyMS),
-				pink(errorCount),
-				pink(totalDowntimeS),
-			)
+	fmt.Printf(
+		"latency: %s, errors: %s, total downtime: %s\n",
+		blue(latencyMS),
+		pink(errorCount),
+		pink(totalDowntimeS),
+	)
+}
+
+func (r *WorkloadRunner) checkBalances(repo repo.Repo, name string) BalanceCheck {
+	check := BalanceCheck{
+		Expected: float64(r.Accounts) * r.InitialBalance,
+	}
+
+	// The database may still be recovering from the last experiment, so
+	// allow a few attempts before giving up.
+	for i := range balanceCheckAttempts {
+		if i > 0 {
+			time.Sleep(time.Second * 2)
 		}
+
+		check.Actual, check.Err = repo.SumBalances()
+		if check.Err == nil {
+			break
+		}
+		log.Printf("error summing balances after %s: %v", name, check.Err)
+	}
+
+	if check.Err != nil {
+		return check
+	}
+
+	check.Drift = check.Actual - check.Expected
+	if !check.Ok() {
+		log.Printf("balance drift after %s: expected %.2f, got %.2f", name, check.Expected, check.Actual)
 	}
+
+	return check
 }
 
-func increment(m map[string]ExperimentStats, name string, d time.Duration) {
-	stats, ok := m[name]
+// collector aggregates the stats of transfers performed by concurrent
+// workers.
+type collector struct {
+	mu                sync.Mutex
+	currentExperiment string
+	errorCount        int
+	totalDowntime     time.Duration
+	totalBehind       int
+	stats             map[string]ExperimentStats
+
+	// recovering is the last experiment to finish, until a transfer has
+	// succeeded since it ended at endedAt.
+	recovering string
+	endedAt    time.Time
+}
+
+// setExperiment records subsequent transfers against an experiment, or the
+// baseline if name is empty, and returns the experiment that ended at the
+// given time, if any.
+func (c *collector) setExperiment(name string, at time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ended := c.currentExperiment
+	if ended != "" {
+		c.recovering = ended
+		c.endedAt = at
+	}
+	c.currentExperiment = name
+
+	return ended
+}
+
+func (c *collector) setPool(name string, pool repo.PoolStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.entry(name)
+	stats.Pool = pool
+	c.stats[name] = stats
+}
+
+// entry returns the stats recorded so far for an experiment.
+func (c *collector) entry(name string) ExperimentStats {
+	stats, ok := c.stats[name]
 	if !ok {
-		stats = ExperimentStats{}
+		stats = ExperimentStats{Latency: histogram.New()}
+	}
+	return stats
+}
+
+// record adds the outcome of a transfer to the current experiment's stats
+// and returns the running error count and downtime.
+func (c *collector) record(taken, latency, lag time.Duration, behind bool, err error) (int, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statsKey := c.currentExperiment
+	if statsKey == "" {
+		statsKey = BaselineExperiment
+	}
+
+	stats := c.entry(statsKey)
+
+	switch {
+	case err == nil:
+		stats.Latency.Record(latency)
+
+	case !errors.Is(err, context.Canceled):
+		c.errorCount++
+		c.totalDowntime += taken
+
+		stats.ErrorCount++
+		stats.Downtime += taken
+	}
+
+	if behind {
+		c.totalBehind++
+		stats.Behind++
 	}
+	stats.MaxLag = max(stats.MaxLag, lag)
 
-	stats.ErrorCount++
-	stats.Downtime += d
+	c.stats[statsKey] = stats
+
+	if err == nil && c.recovering != "" {
+		recovered := c.entry(c.recovering)
+		recovered.Recovery = time.Since(c.endedAt)
+		c.stats[c.recovering] = recovered
+		c.recovering = ""
+	}
 
-	m[name] = stats
+	return c.errorCount, c.totalDowntime
 }
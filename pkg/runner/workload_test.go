@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCollectorRecovery(t *testing.T) {
+	errTransfer := errors.New("connection refused")
+
+	cases := []struct {
+		name string
+
+		// ago is how long before the first transfer the experiment ended,
+		// and results the outcome of each transfer since.
+		ago     time.Duration
+		results []error
+
+		expMin time.Duration
+		expMax time.Duration
+	}{
+		{
+			name:    "first transfer succeeds",
+			ago:     time.Second,
+			results: []error{nil},
+			expMin:  time.Second,
+			expMax:  2 * time.Second,
+		},
+		{
+			name:    "failures before success",
+			ago:     time.Second,
+			results: []error{errTransfer, errTransfer, nil, nil},
+			expMin:  time.Second,
+			expMax:  2 * time.Second,
+		},
+		{
+			name:    "no success",
+			ago:     time.Second,
+			results: []error{errTransfer},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			col := &collector{stats: map[string]ExperimentStats{}}
+
+			// Transfers during the experiment don't end its recovery.
+			col.setExperiment("exp", time.Now())
+			col.record(time.Millisecond, time.Millisecond, 0, false, nil)
+
+			if ended := col.setExperiment("", time.Now().Add(-c.ago)); ended != "exp" {
+				t.Fatalf("expected exp to have ended, got %q", ended)
+			}
+
+			for _, err := range c.results {
+				col.record(time.Millisecond, time.Millisecond, 0, false, err)
+			}
+
+			act := col.stats["exp"].Recovery
+			if act < c.expMin || act > c.expMax {
+				t.Fatalf("expected recovery between %s and %s, got %s", c.expMin, c.expMax, act)
+			}
+
+			if col.stats[BaselineExperiment].Recovery != 0 {
+				t.Fatalf("expected no recovery for the baseline, got %s", col.stats[BaselineExperiment].Recovery)
+			}
+		})
+	}
+}